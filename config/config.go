@@ -1,8 +1,13 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -22,6 +27,59 @@ type Config struct {
 	LogLevel    string
 	LogFormat   string
 	CORSOrigins string
+	MFAIssuer   string
+
+	LoginMaxAttempts   int
+	LoginIPMaxAttempts int
+	LoginAttemptWindow time.Duration
+	LoginLockoutBase   time.Duration
+	LoginLockoutMax    time.Duration
+
+	PasswordHasher string
+	Argon2Memory   uint32
+	Argon2Time     uint32
+	Argon2Threads  uint8
+	BcryptCost     int
+
+	PasswordMinLength    int
+	PasswordMaxLength    int
+	PasswordMinEntropy   float64
+	PasswordBreachedFile string
+
+	CookieDomain   string
+	CookieSecure   bool
+	CookieSameSite string
+
+	AccountDeletionGrace    time.Duration
+	AccountDeletionInterval time.Duration
+	ImpersonationTTL        time.Duration
+
+	ModerateGuests         bool
+	ModerateNewAccountDays int
+	ModerateLinks          bool
+
+	ReportHideThreshold int
+
+	SpamMaxLinks        int
+	SpamBadDomainsFile  string
+	SpamQueueThreshold  float64
+	SpamRejectThreshold float64
+	SpamMinTrainingDocs int
+
+	DuplicateWindow      time.Duration
+	DuplicateMaxDistance int
+
+	TrustBasicDays        int
+	TrustBasicApproved    int
+	TrustMemberDays       int
+	TrustMemberApproved   int
+	TrustNewHourlyLimit   int
+	TrustBasicHourlyLimit int
+
+	CommentsAutoCloseDays int
+	MaxPinnedComments     int
+
+	TrustedProxies []string
 }
 
 var AppConfig *Config
@@ -41,13 +99,91 @@ func LoadConfig() *Config {
 		LogLevel:    getEnv("LOG_LEVEL", "debug"),
 		LogFormat:   getEnv("LOG_FORMAT", "json"),
 		CORSOrigins: getEnv("CORS_ORIGINS", "*"),
+		MFAIssuer:   getEnv("MFA_ISSUER", "PostComments"),
+
+		PasswordHasher:       getEnv("PASSWORD_HASHER", "argon2id"),
+		PasswordBreachedFile: getEnv("PASSWORD_BREACHED_FILE", ""),
+		CookieDomain:         getEnv("COOKIE_DOMAIN", ""),
+		CookieSameSite:       getEnv("COOKIE_SAMESITE", "lax"),
+		SpamBadDomainsFile:   getEnv("SPAM_BAD_DOMAINS_FILE", ""),
 	}
 	cfg.RateLimit, _ = strconv.Atoi(getEnv("RATE_LIMIT", "5"))
 	cfg.RateBurst, _ = strconv.Atoi(getEnv("RATE_BURST", "10"))
+	cfg.LoginMaxAttempts, _ = strconv.Atoi(getEnv("LOGIN_MAX_ATTEMPTS", "5"))
+	cfg.LoginIPMaxAttempts, _ = strconv.Atoi(getEnv("LOGIN_IP_MAX_ATTEMPTS", "20"))
+	cfg.LoginAttemptWindow, _ = time.ParseDuration(getEnv("LOGIN_ATTEMPT_WINDOW", "15m"))
+	cfg.LoginLockoutBase, _ = time.ParseDuration(getEnv("LOGIN_LOCKOUT_BASE", "1m"))
+	cfg.LoginLockoutMax, _ = time.ParseDuration(getEnv("LOGIN_LOCKOUT_MAX", "1h"))
+	cfg.Argon2Memory = uint32(mustParseUint("ARGON2_MEMORY", "65536", 32))
+	cfg.Argon2Time = uint32(mustParseUint("ARGON2_TIME", "3", 32))
+	cfg.Argon2Threads = uint8(mustParseUint("ARGON2_THREADS", "2", 8))
+	cfg.BcryptCost, _ = strconv.Atoi(getEnv("BCRYPT_COST", "12"))
+	cfg.PasswordMinLength, _ = strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	cfg.PasswordMaxLength, _ = strconv.Atoi(getEnv("PASSWORD_MAX_LENGTH", "128"))
+	cfg.PasswordMinEntropy, _ = strconv.ParseFloat(getEnv("PASSWORD_MIN_ENTROPY", "40"), 64)
+	cfg.AccountDeletionGrace, _ = time.ParseDuration(getEnv("ACCOUNT_DELETION_GRACE", "720h"))
+	cfg.AccountDeletionInterval, _ = time.ParseDuration(getEnv("ACCOUNT_DELETION_INTERVAL", "1h"))
+	cfg.ImpersonationTTL, _ = time.ParseDuration(getEnv("IMPERSONATION_TTL", "15m"))
+	cfg.ModerateGuests, _ = strconv.ParseBool(getEnv("MODERATE_GUESTS", "true"))
+	cfg.ModerateNewAccountDays, _ = strconv.Atoi(getEnv("MODERATE_NEW_ACCOUNT_DAYS", "0"))
+	cfg.ModerateLinks, _ = strconv.ParseBool(getEnv("MODERATE_LINKS", "false"))
+	cfg.ReportHideThreshold, _ = strconv.Atoi(getEnv("REPORT_HIDE_THRESHOLD", "3"))
+	cfg.SpamMaxLinks, _ = strconv.Atoi(getEnv("SPAM_MAX_LINKS", "2"))
+	cfg.SpamQueueThreshold, _ = strconv.ParseFloat(getEnv("SPAM_QUEUE_THRESHOLD", "0.5"), 64)
+	cfg.SpamRejectThreshold, _ = strconv.ParseFloat(getEnv("SPAM_REJECT_THRESHOLD", "0.95"), 64)
+	cfg.SpamMinTrainingDocs, _ = strconv.Atoi(getEnv("SPAM_MIN_TRAINING_DOCS", "10"))
+	cfg.DuplicateWindow, _ = time.ParseDuration(getEnv("DUPLICATE_WINDOW", "24h"))
+	cfg.DuplicateMaxDistance, _ = strconv.Atoi(getEnv("DUPLICATE_MAX_DISTANCE", "8"))
+	cfg.TrustBasicDays, _ = strconv.Atoi(getEnv("TRUST_BASIC_DAYS", "3"))
+	cfg.TrustBasicApproved, _ = strconv.Atoi(getEnv("TRUST_BASIC_APPROVED", "3"))
+	cfg.TrustMemberDays, _ = strconv.Atoi(getEnv("TRUST_MEMBER_DAYS", "30"))
+	cfg.TrustMemberApproved, _ = strconv.Atoi(getEnv("TRUST_MEMBER_APPROVED", "20"))
+	cfg.TrustNewHourlyLimit, _ = strconv.Atoi(getEnv("TRUST_NEW_HOURLY_LIMIT", "5"))
+	cfg.TrustBasicHourlyLimit, _ = strconv.Atoi(getEnv("TRUST_BASIC_HOURLY_LIMIT", "20"))
+	cfg.CommentsAutoCloseDays, _ = strconv.Atoi(getEnv("COMMENTS_AUTO_CLOSE_DAYS", "0"))
+	cfg.MaxPinnedComments, _ = strconv.Atoi(getEnv("MAX_PINNED_COMMENTS", "3"))
+	for _, proxy := range strings.Split(getEnv("TRUSTED_PROXIES", ""), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.TrustedProxies = append(cfg.TrustedProxies, proxy)
+		}
+	}
+	cfg.CookieSecure, _ = strconv.ParseBool(getEnv("COOKIE_SECURE", strconv.FormatBool(cfg.Env != "development")))
+	if err := cfg.validatePasswordHashing(); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
 	AppConfig = cfg
 	return cfg
 }
 
+// validatePasswordHashing rejects hasher settings that would make every
+// login fail or panic.
+func (cfg *Config) validatePasswordHashing() error {
+	if cfg.PasswordHasher != "argon2id" && cfg.PasswordHasher != "bcrypt" {
+		return fmt.Errorf("PASSWORD_HASHER must be argon2id or bcrypt, got %q", cfg.PasswordHasher)
+	}
+	if cfg.Argon2Time < 1 {
+		return errors.New("ARGON2_TIME must be at least 1")
+	}
+	if cfg.Argon2Threads < 1 {
+		return errors.New("ARGON2_THREADS must be between 1 and 255")
+	}
+	if cfg.Argon2Memory < 8*uint32(cfg.Argon2Threads) {
+		return errors.New("ARGON2_MEMORY must be at least 8 KiB per thread")
+	}
+	if cfg.BcryptCost < 4 || cfg.BcryptCost > 31 {
+		return errors.New("BCRYPT_COST must be between 4 and 31")
+	}
+	return nil
+}
+
+func mustParseUint(key, fallback string, bits int) uint64 {
+	value, err := strconv.ParseUint(getEnv(key, fallback), 10, bits)
+	if err != nil {
+		log.Fatalf("invalid configuration: %s: %v", key, err)
+	}
+	return value
+}
+
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
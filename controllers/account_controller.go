@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+	Content  string `json:"content" binding:"required,oneof=anonymize delete"`
+}
+
+// ExportAccount streams a ZIP of the user's profile, posts and comments, each
+// as JSON plus one Markdown file per post and comment.
+func ExportAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var posts []models.Post
+	var comments []models.Comment
+	if err := utils.GetDB().Where("user_id = ?", user.ID).Order("created_at ASC").Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export posts"})
+		return
+	}
+	if err := utils.GetDB().Where("user_id = ?", user.ID).Order("created_at ASC").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export comments"})
+		return
+	}
+
+	archive, err := buildAccountExport(user, posts, comments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build export"})
+		return
+	}
+	filename := fmt.Sprintf("%s-export-%s.zip", user.Username, time.Now().Format("20060102"))
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// buildAccountExport assembles the export in memory so a failure can still be
+// reported as an error instead of a truncated download. Posts and comments go
+// through the public response shape, leaving out internal moderation fields.
+func buildAccountExport(user models.User, posts []models.Post, comments []models.Comment) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	profile := userProfile(user)
+	profile["role"] = user.Role
+	profile["totp_enabled"] = user.TOTPEnabled
+	exportedPosts := []gin.H{}
+	for _, post := range posts {
+		exportedPosts = append(exportedPosts, postResponse(post))
+	}
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"profile.json", profile},
+		{"posts.json", exportedPosts},
+		{"comments.json", commentResponses(comments)},
+	}
+	for _, f := range files {
+		if err := writeZipJSON(zw, f.name, f.v); err != nil {
+			return nil, err
+		}
+	}
+	for _, post := range posts {
+		if err := writeZipFile(zw, fmt.Sprintf("posts/%d.md", post.ID),
+			fmt.Sprintf("# %s\n\n_Posted %s_\n\n%s\n", post.Title, post.CreatedAt.Format(time.RFC3339), post.Content)); err != nil {
+			return nil, err
+		}
+	}
+	for _, comment := range comments {
+		if err := writeZipFile(zw, fmt.Sprintf("comments/%d.md", comment.ID),
+			fmt.Sprintf("_Comment on post %d, %s_\n\n%s\n", comment.PostID, comment.CreatedAt.Format(time.RFC3339), comment.Content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeZipFile(zw, name, string(data))
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(content))
+	return err
+}
+
+// DeleteAccount schedules the account for deletion after the configured grace
+// period and signs out every session. Logging in again during the grace
+// period allows the deletion to be cancelled.
+func DeleteAccount(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if ok, _, err := utils.VerifyPassword(user.Password, req.Password); !ok || err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return
+	}
+	scheduledAt := time.Now().Add(config.AppConfig.AccountDeletionGrace)
+	// API keys are revoked rather than suspended: a cancelled deletion means
+	// logging in again, after which new keys can be issued.
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"deletion_scheduled_at": scheduledAt,
+			"deletion_mode":         req.Content,
+		}).Error; err != nil {
+			return err
+		}
+		if err := utils.RevokeUserSessions(tx, user.ID); err != nil {
+			return err
+		}
+		return utils.RevokeUserAPIKeys(tx, user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to schedule account deletion"})
+		return
+	}
+	utils.ClearSessionCookies(c.Writer)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":               "Account scheduled for deletion",
+		"deletion_scheduled_at": scheduledAt,
+		"content":               req.Content,
+	})
+}
+
+func CancelAccountDeletion(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Account is not scheduled for deletion"})
+		return
+	}
+	if err := utils.GetDB().Model(&user).Updates(map[string]interface{}{
+		"deletion_scheduled_at": nil,
+		"deletion_mode":         "",
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel account deletion"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
@@ -0,0 +1,448 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type SuspendUserRequest struct {
+	Reason    string     `json:"reason" binding:"required,max=500"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type ChangeRoleRequest struct {
+	Role   string `json:"role" binding:"required,oneof=user moderator admin"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+func AdminListUsers(c *gin.Context) {
+	page, pageSize, offset := paginationParams(c)
+	query := utils.GetDB().Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		like := "%" + strings.ToLower(q) + "%"
+		query = query.Where("LOWER(username) LIKE ? OR LOWER(display_name) LIKE ?", like, like)
+	}
+	if role := c.Query("role"); role != "" {
+		query = query.Where("role = ?", role)
+	}
+	now := time.Now()
+	switch c.Query("status") {
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL AND (suspended_until IS NULL OR suspended_until > ?)", now)
+	case "active":
+		query = query.Where("suspended_at IS NULL OR (suspended_until IS NOT NULL AND suspended_until <= ?)", now)
+	case "shadow_banned":
+		query = query.Where("shadow_banned_at IS NOT NULL")
+	case "pending_deletion":
+		query = query.Where("deletion_scheduled_at IS NOT NULL")
+	}
+	var total int64
+	var users []models.User
+	query.Count(&total)
+	if err := query.Limit(pageSize).Offset(offset).Order("id ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	resp := []gin.H{}
+	for _, user := range users {
+		resp = append(resp, adminUserResponse(user))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"users":      resp,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+// AdminGetUser returns the account with a summary of recent activity.
+func AdminGetUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	db := utils.GetDB()
+	var postCount, commentCount, activeSessions int64
+	db.Model(&models.Post{}).Where("user_id = ?", user.ID).Count(&postCount)
+	db.Model(&models.Comment{}).Where("user_id = ?", user.ID).Count(&commentCount)
+	db.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", user.ID, time.Now()).
+		Count(&activeSessions)
+	var lastSession models.Session
+	var lastSeen *time.Time
+	if err := db.Where("user_id = ? AND impersonator_id IS NULL", user.ID).Order("last_seen_at DESC").First(&lastSession).Error; err == nil {
+		lastSeen = &lastSession.LastSeenAt
+	}
+	var recentPosts []models.Post
+	var recentComments []models.Comment
+	db.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(5).Find(&recentPosts)
+	db.Where("user_id = ?", user.ID).Order("created_at DESC").Limit(5).Find(&recentComments)
+	var throttle models.LoginThrottle
+	failedLogins := 0
+	if err := db.Where("key = ?", utils.UsernameThrottleKey(user.Username)).First(&throttle).Error; err == nil {
+		failedLogins = throttle.Failures
+	}
+
+	resp := adminUserResponse(user)
+	// Trust level costs two counts, so only the single-user view includes it.
+	resp["trust_level"] = utils.TrustLevel(&user)
+	resp["activity"] = gin.H{
+		"post_count":      postCount,
+		"comment_count":   commentCount,
+		"active_sessions": activeSessions,
+		"last_seen_at":    lastSeen,
+		"failed_logins":   failedLogins,
+		"recent_posts":    recentPosts,
+		"recent_comments": recentComments,
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// SuspendUser suspends an account until expires_at, or bans it permanently
+// when no expiry is given, signs out all of its sessions and revokes its API
+// keys.
+func SuspendUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID := c.GetUint("userID")
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend yourself"})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.suspend", map[string]interface{}{
+		"suspended_at":      time.Now(),
+		"suspended_until":   req.ExpiresAt,
+		"suspension_reason": req.Reason,
+		"suspended_by":      adminID,
+	}, req.Reason, true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suspend user"})
+		return
+	}
+	utils.SecurityLog("user_suspended").
+		Uint("user_id", user.ID).
+		Uint("admin_id", adminID).
+		Str("reason", req.Reason).
+		Msg("user suspended by admin")
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+func UnsuspendUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.unsuspend", map[string]interface{}{
+		"suspended_at":      nil,
+		"suspended_until":   nil,
+		"suspension_reason": "",
+		"suspended_by":      nil,
+	}, "", false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift suspension"})
+		return
+	}
+	utils.SecurityLog("user_unsuspended").
+		Uint("user_id", user.ID).
+		Uint("admin_id", c.GetUint("userID")).
+		Msg("suspension lifted by admin")
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// ShadowBanUser hides the user's posts and comments from everyone but the user,
+// who is not told.
+func ShadowBanUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	adminID := c.GetUint("userID")
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot shadow-ban yourself"})
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.shadow_ban", map[string]interface{}{
+		"shadow_banned_at": time.Now(),
+		"shadow_banned_by": adminID,
+	}, "", false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to shadow-ban user"})
+		return
+	}
+	utils.SecurityLog("user_shadow_banned").
+		Uint("user_id", user.ID).
+		Uint("admin_id", adminID).
+		Msg("user shadow-banned by admin")
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+func UnshadowBanUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.unshadow_ban", map[string]interface{}{
+		"shadow_banned_at": nil,
+		"shadow_banned_by": nil,
+	}, "", false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lift shadow ban"})
+		return
+	}
+	utils.SecurityLog("user_shadow_ban_lifted").
+		Uint("user_id", user.ID).
+		Uint("admin_id", c.GetUint("userID")).
+		Msg("shadow ban lifted by admin")
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// AdminResetPassword replaces the user's password with a random temporary one,
+// returned once in the response, signs out all of their sessions and revokes
+// their API keys.
+func AdminResetPassword(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password"})
+		return
+	}
+	temporary := base64.RawURLEncoding.EncodeToString(b)
+	hash, err := utils.HashPassword(temporary)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.reset_password", map[string]interface{}{"password": hash}, "", true); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+		return
+	}
+	utils.SecurityLog("password_reset").
+		Uint("user_id", user.ID).
+		Uint("admin_id", c.GetUint("userID")).
+		Msg("password reset by admin")
+	c.JSON(http.StatusOK, gin.H{"temporary_password": temporary})
+}
+
+func ChangeUserRole(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	var req ChangeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	adminID := c.GetUint("userID")
+	if user.ID == adminID && req.Role != models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot remove your own admin role"})
+		return
+	}
+	if err := updateUserAudited(c, &user, "user.change_role", map[string]interface{}{"role": req.Role}, req.Reason, false); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change role"})
+		return
+	}
+	utils.SecurityLog("role_changed").
+		Uint("user_id", user.ID).
+		Uint("admin_id", adminID).
+		Str("role", req.Role).
+		Msg("user role changed by admin")
+	c.JSON(http.StatusOK, adminUserResponse(user))
+}
+
+// ForceLogoutUser signs out every session of the user and revokes their API
+// keys.
+func ForceLogoutUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := revokeUserAccess(tx, user.ID); err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "user.force_logout", auditTargetUser, user.ID, nil, nil, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out user"})
+		return
+	}
+	utils.SecurityLog("forced_logout").
+		Uint("user_id", user.ID).
+		Uint("admin_id", c.GetUint("userID")).
+		Msg("all sessions revoked by admin")
+	c.JSON(http.StatusOK, gin.H{"message": "User signed out of all sessions"})
+}
+
+func UnlockUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := utils.ClearLoginFailures(tx, utils.UsernameThrottleKey(user.Username)); err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "user.unlock", auditTargetUser, user.ID, nil, nil, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+	utils.SecurityLog("account_unlocked").
+		Uint("user_id", user.ID).
+		Uint("admin_id", c.GetUint("userID")).
+		Msg("account unlocked by admin")
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked"})
+}
+
+// ImpersonateUser issues a short-lived token that acts as the target user
+// while carrying the admin's ID. Every request made with it is audited.
+func ImpersonateUser(c *gin.Context) {
+	user, ok := findAdminTarget(c)
+	if !ok {
+		return
+	}
+	adminID := c.GetUint("userID")
+	if user.ID == adminID || user.Role == models.RoleAdmin {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Admins cannot be impersonated"})
+		return
+	}
+	if utils.IsSuspended(&user) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Suspended users cannot be impersonated"})
+		return
+	}
+	// The token is only handed out once its session and both audit entries
+	// are committed.
+	var token string
+	var expiresAt time.Time
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		session, err := utils.CreateImpersonationSession(tx, user.ID, adminID, c.Request.UserAgent(), c.ClientIP())
+		if err != nil {
+			return err
+		}
+		if token, err = utils.GenerateImpersonationJWT(session); err != nil {
+			return err
+		}
+		expiresAt = session.ExpiresAt
+		if err := tx.Create(&models.ImpersonationAudit{
+			ImpersonatorID: adminID,
+			UserID:         user.ID,
+			Method:         c.Request.Method,
+			Path:           utils.TruncateString(c.Request.URL.RequestURI(), 500),
+			Status:         http.StatusOK,
+			IP:             c.ClientIP(),
+			UserAgent:      utils.TruncateString(c.Request.UserAgent(), 255),
+		}).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "user.impersonate", auditTargetUser, user.ID, nil, nil, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		return
+	}
+	utils.SecurityLog("impersonation_started").
+		Uint("user_id", user.ID).
+		Uint("admin_id", adminID).
+		Time("expires_at", expiresAt).
+		Msg("admin started impersonating user")
+	c.JSON(http.StatusOK, gin.H{"token": token, "expires_at": expiresAt})
+}
+
+func ListImpersonationAudit(c *gin.Context) {
+	page, pageSize, offset := paginationParams(c)
+	query := utils.GetDB().Model(&models.ImpersonationAudit{})
+	if userID := c.Query("user_id"); userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if adminID := c.Query("impersonator_id"); adminID != "" {
+		query = query.Where("impersonator_id = ?", adminID)
+	}
+	var total int64
+	var entries []models.ImpersonationAudit
+	query.Count(&total)
+	if err := query.Limit(pageSize).Offset(offset).Order("created_at DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries":    entries,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+// updateUserAudited applies updates to user and records action in the
+// moderation audit log in one transaction, then reloads user. With
+// revokeAccess the user's sessions and API keys are revoked in the same
+// transaction.
+func updateUserAudited(c *gin.Context, user *models.User, action string, updates map[string]interface{}, reason string, revokeAccess bool) error {
+	before := adminUserResponse(*user)
+	return utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(updates).Error; err != nil {
+			return err
+		}
+		if err := tx.First(user, user.ID).Error; err != nil {
+			return err
+		}
+		if revokeAccess {
+			if err := revokeUserAccess(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		return recordModeration(tx, c, action, auditTargetUser, user.ID, before, adminUserResponse(*user), reason)
+	})
+}
+
+func findAdminTarget(c *gin.Context) (models.User, bool) {
+	var user models.User
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return user, false
+	}
+	if err := utils.GetDB().First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
+}
+
+func adminUserResponse(user models.User) gin.H {
+	resp := userProfile(user)
+	resp["role"] = user.Role
+	resp["totp_enabled"] = user.TOTPEnabled
+	resp["suspended"] = utils.IsSuspended(&user)
+	resp["suspended_at"] = user.SuspendedAt
+	resp["suspended_until"] = user.SuspendedUntil
+	resp["suspension_reason"] = user.SuspensionReason
+	resp["suspended_by"] = user.SuspendedBy
+	resp["shadow_banned_at"] = user.ShadowBannedAt
+	resp["deletion_scheduled_at"] = user.DeletionScheduledAt
+	return resp
+}
+
+// revokeUserAccess signs out every session of userID and revokes its API keys.
+func revokeUserAccess(tx *gorm.DB, userID uint) error {
+	if err := utils.RevokeUserSessions(tx, userID); err != nil {
+		return err
+	}
+	return utils.RevokeUserAPIKeys(tx, userID)
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+const apiKeyCreateAttempts = 5
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=posts:read posts:write comments:read comments:write"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var keys []models.APIKey
+	if err := utils.GetDB().Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	resp := []gin.H{}
+	for _, key := range keys {
+		resp = append(resp, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": resp})
+}
+
+func CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	var key string
+	var apiKey models.APIKey
+	// Prefixes are short and stay reserved by revoked keys, so retry the
+	// rare collision with a fresh key.
+	for attempt := 0; ; attempt++ {
+		var prefix string
+		var err error
+		key, prefix, err = utils.GenerateAPIKey()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate API key"})
+			return
+		}
+		apiKey = models.APIKey{
+			UserID:    userID.(uint),
+			Name:      req.Name,
+			Prefix:    prefix,
+			KeyHash:   utils.HashAPIKey(key),
+			Scopes:    strings.Join(uniqueStrings(req.Scopes), ","),
+			ExpiresAt: req.ExpiresAt,
+		}
+		err = utils.GetDB().Create(&apiKey).Error
+		if err == nil {
+			break
+		}
+		if !utils.IsDuplicateKey(err) || attempt == apiKeyCreateAttempts-1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+			return
+		}
+	}
+	resp := apiKeyResponse(apiKey)
+	resp["key"] = key
+	c.JSON(http.StatusCreated, resp)
+}
+
+func DeleteAPIKey(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+	var apiKey models.APIKey
+	if err := utils.GetDB().Where("user_id = ?", userID).First(&apiKey, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err := utils.GetDB().Delete(&apiKey).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func apiKeyResponse(key models.APIKey) gin.H {
+	return gin.H{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       strings.Split(key.Scopes, ","),
+		"expires_at":   key.ExpiresAt,
+		"last_used_at": key.LastUsedAt,
+		"created_at":   key.CreatedAt,
+	}
+}
+
+func uniqueStrings(list []string) []string {
+	seen := make(map[string]bool, len(list))
+	out := make([]string, 0, len(list))
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// Moderation audit target types, alongside the report target types.
+const (
+	auditTargetUser       = "user"
+	auditTargetWordFilter = "word_filter"
+	auditTargetIPBan      = "ip_ban"
+)
+
+// recordModeration appends an action by the current user to the moderation
+// audit log, with JSON snapshots of the target before and after. Pass the
+// transaction making the change so the entry is written atomically with it;
+// a failure is logged and returned.
+func recordModeration(tx *gorm.DB, c *gin.Context, action, targetType string, targetID uint, before, after interface{}, reason string) error {
+	entry := models.ModerationAudit{
+		ActorID:    c.GetUint("userID"),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     auditSnapshot(before),
+		After:      auditSnapshot(after),
+		Reason:     utils.TruncateString(reason, 500),
+		Method:     c.Request.Method,
+		Path:       utils.TruncateString(c.Request.URL.RequestURI(), 500),
+		IP:         c.ClientIP(),
+		UserAgent:  utils.TruncateString(c.Request.UserAgent(), 255),
+	}
+	if impersonatorID, ok := c.Get("impersonatorID"); ok {
+		id := impersonatorID.(uint)
+		entry.ImpersonatorID = &id
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		log.Error().Err(err).Str("action", action).Uint("target_id", targetID).Msg("failed to record moderation action")
+		return err
+	}
+	return nil
+}
+
+func auditSnapshot(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// ListModerationAudit returns moderation audit entries, newest first,
+// filtered by actor_id, action, target_type, target_id, since and until.
+// With format=csv the full filtered log is streamed as CSV instead.
+func ListModerationAudit(c *gin.Context) {
+	query := utils.GetDB().Model(&models.ModerationAudit{})
+	for _, param := range []string{"actor_id", "action", "target_type", "target_id"} {
+		if value := c.Query(param); value != "" {
+			query = query.Where(param+" = ?", value)
+		}
+	}
+	for param, op := range map[string]string{"since": ">=", "until": "<"} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC 3339 timestamp"})
+				return
+			}
+			query = query.Where("created_at "+op+" ?", t)
+		}
+	}
+	if c.Query("format") == "csv" {
+		exportModerationAudit(c, query)
+		return
+	}
+	page, pageSize, offset := paginationParams(c)
+	var total int64
+	var entries []models.ModerationAudit
+	query.Count(&total)
+	if err := query.Limit(pageSize).Offset(offset).Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	resp := []gin.H{}
+	for _, entry := range entries {
+		resp = append(resp, gin.H{
+			"id":              entry.ID,
+			"actor_id":        entry.ActorID,
+			"impersonator_id": entry.ImpersonatorID,
+			"action":          entry.Action,
+			"target_type":     entry.TargetType,
+			"target_id":       entry.TargetID,
+			"before":          rawSnapshot(entry.Before),
+			"after":           rawSnapshot(entry.After),
+			"reason":          entry.Reason,
+			"method":          entry.Method,
+			"path":            entry.Path,
+			"ip":              entry.IP,
+			"user_agent":      entry.UserAgent,
+			"created_at":      entry.CreatedAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries":    resp,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+func exportModerationAudit(c *gin.Context, query *gorm.DB) {
+	rows, err := query.Order("created_at ASC, id ASC").Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export audit log"})
+		return
+	}
+	defer rows.Close()
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="moderation-audit.csv"`)
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor_id", "impersonator_id", "action", "target_type", "target_id", "reason", "before", "after", "method", "path", "ip", "user_agent"})
+	for rows.Next() {
+		var entry models.ModerationAudit
+		if err := utils.GetDB().ScanRows(rows, &entry); err != nil {
+			log.Error().Err(err).Msg("failed to export audit log row")
+			break
+		}
+		impersonator := ""
+		if entry.ImpersonatorID != nil {
+			impersonator = strconv.FormatUint(uint64(*entry.ImpersonatorID), 10)
+		}
+		w.Write([]string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatUint(uint64(entry.ActorID), 10),
+			impersonator,
+			csvSafe(entry.Action),
+			entry.TargetType,
+			strconv.FormatUint(uint64(entry.TargetID), 10),
+			csvSafe(entry.Reason),
+			entry.Before,
+			entry.After,
+			entry.Method,
+			csvSafe(entry.Path),
+			entry.IP,
+			csvSafe(entry.UserAgent),
+		})
+	}
+	w.Flush()
+}
+
+// csvSafe stops spreadsheet apps from treating a cell as a formula.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func rawSnapshot(snapshot string) json.RawMessage {
+	if snapshot == "" {
+		return nil
+	}
+	return json.RawMessage(snapshot)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/utils"
+)
+
+const (
+	defaultAvatarSize = 80
+	minAvatarSize     = 16
+	maxAvatarSize     = 512
+)
+
+// GetAvatar serves /api/avatars/:file where file is <hash>.png or <hash>.svg.
+// Images depend only on the hash and size, so they are cached indefinitely.
+func GetAvatar(c *gin.Context) {
+	file := c.Param("file")
+	var hash, format string
+	switch {
+	case strings.HasSuffix(file, ".png"):
+		hash, format = strings.TrimSuffix(file, ".png"), "png"
+	case strings.HasSuffix(file, ".svg"):
+		hash, format = strings.TrimSuffix(file, ".svg"), "svg"
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+	hash = strings.ToLower(hash)
+	if !utils.IsAvatarHash(hash) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Avatar not found"})
+		return
+	}
+	size := defaultAvatarSize
+	if s := c.Query("size"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < minAvatarSize || v > maxAvatarSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "size must be between 16 and 512"})
+			return
+		}
+		size = v
+	}
+	etag := `"` + hash + "-" + strconv.Itoa(size) + "-" + format + `"`
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if format == "svg" {
+		c.Data(http.StatusOK, "image/svg+xml", []byte(utils.RenderIdenticonSVG(hash, size)))
+		return
+	}
+	img, err := utils.RenderIdenticonPNG(hash, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render avatar"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", img)
+}
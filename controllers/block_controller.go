@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// BlockUser blocks the target and removes any follow between the two users.
+func BlockUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	uid := userID.(uint)
+	if target.ID == uid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself"})
+		return
+	}
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		block := models.Block{BlockerID: uid, BlockedID: target.ID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&block).Error; err != nil {
+			return err
+		}
+		return tx.Where("(follower_id = ? AND followee_id = ?) OR (follower_id = ? AND followee_id = ?)",
+			uid, target.ID, target.ID, uid).Delete(&models.Follow{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Blocked " + target.Username})
+}
+
+func UnblockUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if err := utils.GetDB().Where("blocker_id = ? AND blocked_id = ?", userID, target.ID).Delete(&models.Block{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unblocked " + target.Username})
+}
+
+func MuteUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if target.ID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot mute yourself"})
+		return
+	}
+	mute := models.Mute{MuterID: userID.(uint), MutedID: target.ID}
+	if err := utils.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&mute).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mute user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Muted " + target.Username})
+}
+
+func UnmuteUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if err := utils.GetDB().Where("muter_id = ? AND muted_id = ?", userID, target.ID).Delete(&models.Mute{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmute user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unmuted " + target.Username})
+}
+
+func ListBlocks(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	listRelatedUsers(c, "SELECT blocked_id FROM blocks WHERE blocker_id = ?", userID, "blocks")
+}
+
+func ListMutes(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	listRelatedUsers(c, "SELECT muted_id FROM mutes WHERE muter_id = ?", userID, "mutes")
+}
+
+func listRelatedUsers(c *gin.Context, subquery string, userID interface{}, key string) {
+	var users []models.User
+	if err := utils.GetDB().Where("id IN ("+subquery+")", userID).Order("username ASC").Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	resp := []gin.H{}
+	for _, user := range users {
+		resp = append(resp, userProfile(user))
+	}
+	c.JSON(http.StatusOK, gin.H{key: resp})
+}
+
+// isBlockedBy reports whether ownerID has blocked userID.
+func isBlockedBy(ownerID, userID uint) bool {
+	var count int64
+	utils.GetDB().Model(&models.Block{}).Where("blocker_id = ? AND blocked_id = ?", ownerID, userID).Count(&count)
+	return count > 0
+}
@@ -5,6 +5,9 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"post-comments-api/config"
 	"post-comments-api/models"
 	"post-comments-api/utils"
 )
@@ -45,22 +48,46 @@ func CreateComment(c *gin.Context) {
 		return
 	}
 	var post models.Post
-	if err := utils.GetDB().First(&post, postID).Error; err != nil {
+	if err := utils.GetDB().First(&post, postID).Error; err != nil || !canView(c, post.ModerationState, post.UserID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
-	uid := userID.(uint)
+	var author models.User
+	if err := utils.GetDB().First(&author, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if post.UserID != nil && isBlockedBy(*post.UserID, author.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot comment on this post"})
+		return
+	}
+	if !checkCommentsOpen(c, &post, &author) {
+		return
+	}
 	comment := models.Comment{
-		PostID:  postID,
-		UserID:  &uid,
-		Author:  req.Author,
-		Content: req.Content,
+		PostID:          postID,
+		UserID:          &author.ID,
+		Author:          req.Author,
+		Content:         req.Content,
+		ModerationState: utils.InitialModerationState(&author, req.Content),
+	}
+	if !checkTrust(c, &author, true, comment.Content) {
+		return
+	}
+	if !filterWords(c, &comment.ModerationState, &comment.Content) {
+		return
+	}
+	if !screenComment(c, &comment, &author) {
+		return
+	}
+	if !fingerprintComment(c, &comment, &author) {
+		return
 	}
 	if err := utils.GetDB().Create(&comment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
-	c.JSON(http.StatusCreated, comment)
+	c.JSON(createdStatus(comment.ModerationState), commentResponse(comment))
 }
 
 func CreateCommentPublic(c *gin.Context) {
@@ -74,20 +101,33 @@ func CreateCommentPublic(c *gin.Context) {
 		return
 	}
 	var post models.Post
-	if err := utils.GetDB().First(&post, req.PostID).Error; err != nil {
+	if err := utils.GetDB().First(&post, req.PostID).Error; err != nil || post.ModerationState != models.ModerationApproved {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	if !checkCommentsOpen(c, &post, nil) {
+		return
+	}
 	comment := models.Comment{
-		PostID:  req.PostID,
-		Author:  req.Author,
-		Content: req.Content,
+		PostID:          req.PostID,
+		Author:          req.Author,
+		Content:         req.Content,
+		ModerationState: utils.InitialModerationState(nil, req.Content),
+	}
+	if !filterWords(c, &comment.ModerationState, &comment.Content) {
+		return
+	}
+	if !screenComment(c, &comment, nil) {
+		return
+	}
+	if !fingerprintComment(c, &comment, nil) {
+		return
 	}
 	if err := utils.GetDB().Create(&comment).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
 		return
 	}
-	c.JSON(http.StatusCreated, comment)
+	c.JSON(createdStatus(comment.ModerationState), commentResponse(comment))
 }
 
 func GetComments(c *gin.Context) {
@@ -96,57 +136,138 @@ func GetComments(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
 		return
 	}
-	// Pagination params
-	page := 1
-	pageSize := 10
-	maxPageSize := 50
-	if p := c.Query("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
-	}
-	if ps := c.Query("page_size"); ps != "" {
-		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
-			if v > maxPageSize {
-				pageSize = maxPageSize
-			} else {
-				pageSize = v
-			}
-		}
+	var post models.Post
+	if err := utils.GetDB().First(&post, postID).Error; err != nil || !canView(c, post.ModerationState, post.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
 	}
-	offset := (page - 1) * pageSize
+	page, pageSize, offset := paginationParams(c)
 	var comments []models.Comment
 	var total int64
-	utils.GetDB().Model(&models.Comment{}).Where("post_id = ?", postID).Count(&total)
-	if err := utils.GetDB().Where("post_id = ?", postID).Limit(pageSize).Offset(offset).Order("created_at ASC").Find(&comments).Error; err != nil {
+	utils.GetDB().Model(&models.Comment{}).Where("post_id = ?", postID).Scopes(visibleContent(c, "comments")).Count(&total)
+	if err := utils.GetDB().Where("post_id = ?", postID).Scopes(visibleContent(c, "comments")).Limit(pageSize).Offset(offset).Order(pinnedCommentsOrder).Order("created_at ASC").Find(&comments).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
 		return
 	}
 	var resp []gin.H
 	for _, comment := range comments {
-		htmlContent, _ := utils.RenderMarkdown(comment.Content)
-		resp = append(resp, gin.H{
-			"id": comment.ID,
-			"post_id": comment.PostID,
-			"user_id": comment.UserID,
-			"author": comment.Author,
-			"content": comment.Content,
-			"html_content": htmlContent,
-			"created_at": comment.CreatedAt,
-			"updated_at": comment.UpdatedAt,
-		})
+		resp = append(resp, commentResponse(comment))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"comments": resp,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+func GetUserComments(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	page, pageSize, offset := paginationParams(c)
+	var comments []models.Comment
+	var total int64
+	// Comments on deleted posts, or posts the viewer cannot see, are hidden
+	// along with the post so its title does not leak.
+	query := utils.GetDB().Model(&models.Comment{}).
+		Joins("JOIN posts ON posts.id = comments.post_id AND posts.deleted_at IS NULL").
+		Where("comments.user_id = ?", user.ID).
+		Scopes(visibleContent(c, "comments"), visibleContent(c, "posts"))
+	query.Count(&total)
+	if err := query.Limit(pageSize).Offset(offset).Order("comments.created_at DESC").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+		return
+	}
+	postIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		postIDs = append(postIDs, comment.PostID)
+	}
+	var posts []models.Post
+	if len(postIDs) > 0 {
+		utils.GetDB().Select("id", "title").Where("posts.id IN ?", postIDs).Scopes(visibleContent(c, "posts")).Find(&posts)
+	}
+	titles := make(map[uint]string, len(posts))
+	for _, post := range posts {
+		titles[post.ID] = post.Title
+	}
+	resp := []gin.H{}
+	for _, comment := range comments {
+		item := commentResponse(comment)
+		item["post_title"] = titles[comment.PostID]
+		resp = append(resp, item)
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"comments": resp,
-		"pagination": gin.H{
-			"page": page,
-			"page_size": pageSize,
-			"total": total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+		"pagination": paginationResponse(page, pageSize, total),
 	})
 }
 
+// screenComment scores a new comment for spam, sending likely spam to the
+// moderation queue. It responds and returns false when the comment is
+// rejected outright. Moderators and admins are not screened.
+func screenComment(c *gin.Context, comment *models.Comment, author *models.User) bool {
+	if author != nil && author.Role != models.RoleUser {
+		return true
+	}
+	result := utils.ScoreSpam(comment.Content)
+	comment.SpamScore = result.Score
+	cfg := config.AppConfig
+	if result.Score >= cfg.SpamRejectThreshold {
+		log.Info().Float64("score", result.Score).Strs("reasons", result.Reasons).Str("ip", c.ClientIP()).Msg("comment rejected as spam")
+		// The reasons are only logged so spammers cannot tune against them.
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Comment rejected as spam"})
+		return false
+	}
+	if result.Score >= cfg.SpamQueueThreshold {
+		comment.ModerationState = models.ModerationPending
+	}
+	return true
+}
+
+// fingerprintComment records the comment's fingerprints and IP, rejecting
+// exact repeats and queueing near-duplicates of comments on other posts.
+func fingerprintComment(c *gin.Context, comment *models.Comment, author *models.User) bool {
+	otherPosts := func(db *gorm.DB) *gorm.DB {
+		return db.Where("post_id <> ?", comment.PostID)
+	}
+	hash, simHash, near, ok := checkDuplicate(c, "comments", author, comment.Content, otherPosts)
+	if !ok {
+		return false
+	}
+	comment.ContentHash = hash
+	comment.SimHash = simHash
+	comment.IP = c.ClientIP()
+	if near && comment.ModerationState == models.ModerationApproved {
+		comment.ModerationState = models.ModerationPending
+	}
+	return true
+}
+
+func commentResponse(comment models.Comment) gin.H {
+	htmlContent, _ := utils.RenderMarkdown(comment.Content)
+	return gin.H{
+		"id": comment.ID,
+		"post_id": comment.PostID,
+		"user_id": comment.UserID,
+		"author": comment.Author,
+		"avatar_url": utils.AvatarURL(comment.UserID, comment.Author),
+		"content": comment.Content,
+		"html_content": htmlContent,
+		"moderation_state": comment.ModerationState,
+		"pinned": comment.PinnedAt != nil,
+		"created_at": comment.CreatedAt,
+		"updated_at": comment.UpdatedAt,
+	}
+}
+
+func commentResponses(comments []models.Comment) []gin.H {
+	resp := []gin.H{}
+	for _, comment := range comments {
+		resp = append(resp, commentResponse(comment))
+	}
+	return resp
+}
+
 func UpdateComment(c *gin.Context) {
 	userID, exists := c.Get("userID")
 	if !exists {
@@ -173,11 +294,37 @@ func UpdateComment(c *gin.Context) {
 		return
 	}
 	comment.Content = req.Content
-	if err := utils.GetDB().Save(&comment).Error; err != nil {
+	var author models.User
+	if err := utils.GetDB().First(&author, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if !checkTrust(c, &author, false, comment.Content) {
+		return
+	}
+	state := editedModerationState(&author, comment.ModerationState, comment.Content)
+	if !filterWords(c, &state, &comment.Content) {
+		return
+	}
+	// Only the edited columns are written so moderator changes made in the
+	// meantime are not undone.
+	updates := map[string]interface{}{
+		"content":      comment.Content,
+		"content_hash": utils.ContentHash(comment.Content),
+		"sim_hash":     int64(utils.SimHash(comment.Content)),
+	}
+	if state != comment.ModerationState {
+		updates["moderation_state"] = requeueEdited
+	}
+	if err := utils.GetDB().Model(&comment).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
+		return
+	}
+	if err := utils.GetDB().First(&comment, comment.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment"})
 		return
 	}
-	c.JSON(http.StatusOK, comment)
+	c.JSON(http.StatusOK, commentResponse(comment))
 }
 
 func DeleteComment(c *gin.Context) {
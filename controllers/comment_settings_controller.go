@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type CommentSettingsRequest struct {
+	CommentsLocked       *bool      `json:"comments_locked"`
+	CommentsCloseAt      *time.Time `json:"comments_close_at"`
+	ClearCommentsCloseAt bool       `json:"clear_comments_close_at"`
+	SlowModeSeconds      *int       `json:"slow_mode_seconds" binding:"omitempty,min=0,max=86400"`
+}
+
+// UpdateCommentSettings lets the post owner or a moderator lock comments,
+// schedule or clear the closing time and set the slow-mode interval.
+func UpdateCommentSettings(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	var post models.Post
+	if err := utils.GetDB().First(&post, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	isOwner := post.UserID != nil && *post.UserID == user.ID
+	if !isOwner && user.Role == models.RoleUser {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not authorized to change comment settings on this post"})
+		return
+	}
+	var req CommentSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// A setting made by a moderator can only be changed by a moderator. The
+	// owner may re-lock a moderator's lock but does not take it over.
+	moderator := user.Role != models.RoleUser
+	setByOther := func(setBy *uint) bool {
+		return !moderator && setBy != nil && *setBy != user.ID
+	}
+	updates := map[string]interface{}{}
+	if req.CommentsLocked != nil {
+		lockedByOther := post.CommentsLocked && setByOther(post.LockedBy)
+		if lockedByOther && !*req.CommentsLocked {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Comments were locked by a moderator and can only be unlocked by one"})
+			return
+		}
+		updates["comments_locked"] = *req.CommentsLocked
+		if !*req.CommentsLocked {
+			updates["locked_by"] = nil
+		} else if !lockedByOther {
+			updates["locked_by"] = user.ID
+		}
+	}
+	if req.ClearCommentsCloseAt || req.CommentsCloseAt != nil {
+		if post.CommentsCloseAt != nil && setByOther(post.CloseAtSetBy) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "The closing time was set by a moderator and can only be changed by one"})
+			return
+		}
+		if req.ClearCommentsCloseAt {
+			updates["comments_close_at"] = nil
+			updates["close_at_set_by"] = nil
+		} else {
+			updates["comments_close_at"] = *req.CommentsCloseAt
+			updates["close_at_set_by"] = user.ID
+		}
+	}
+	if req.SlowModeSeconds != nil {
+		if post.SlowModeSeconds > 0 && setByOther(post.SlowModeSetBy) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Slow mode was set by a moderator and can only be changed by one"})
+			return
+		}
+		updates["slow_mode_seconds"] = *req.SlowModeSeconds
+		if *req.SlowModeSeconds > 0 {
+			updates["slow_mode_set_by"] = user.ID
+		} else {
+			updates["slow_mode_set_by"] = nil
+		}
+	}
+	if len(updates) > 0 {
+		before := post
+		err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&post).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&post, post.ID).Error; err != nil {
+				return err
+			}
+			// Owners managing their own threads are not moderation.
+			if isOwner {
+				return nil
+			}
+			return recordModeration(tx, c, "post.comment_settings", models.ReportTargetPost, post.ID, before, post, "")
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update comment settings"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, postResponse(post))
+}
+
+// commentsCloseAt is when comments on post close: its own closing time when
+// one is set, otherwise COMMENTS_AUTO_CLOSE_DAYS after it was created. It is
+// computed on each check so changing the setting applies to existing posts.
+func commentsCloseAt(post *models.Post) *time.Time {
+	if post.CommentsCloseAt != nil {
+		return post.CommentsCloseAt
+	}
+	days := config.AppConfig.CommentsAutoCloseDays
+	if days <= 0 || post.CreatedAt.IsZero() {
+		return nil
+	}
+	closeAt := post.CreatedAt.AddDate(0, 0, days)
+	return &closeAt
+}
+
+// commentsOpen reports whether new comments are accepted on post now.
+func commentsOpen(post *models.Post) bool {
+	closeAt := commentsCloseAt(post)
+	return !post.CommentsLocked && (closeAt == nil || closeAt.After(time.Now()))
+}
+
+// checkCommentsOpen enforces the post's lock, closing time and slow mode for
+// a new comment by author, or by the caller's IP for guests. Moderators and
+// admins bypass all three; the post owner bypasses slow mode. It responds and
+// returns false when the comment is refused.
+func checkCommentsOpen(c *gin.Context, post *models.Post, author *models.User) bool {
+	if author != nil && author.Role != models.RoleUser {
+		return true
+	}
+	if post.CommentsLocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments are locked on this post", "code": "comments_locked"})
+		return false
+	}
+	if closeAt := commentsCloseAt(post); closeAt != nil && !closeAt.After(time.Now()) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments are closed on this post", "code": "comments_closed"})
+		return false
+	}
+	if post.SlowModeSeconds <= 0 || (author != nil && post.UserID != nil && *post.UserID == author.ID) {
+		return true
+	}
+	query := utils.GetDB().Unscoped().Model(&models.Comment{}).Where("post_id = ?", post.ID)
+	if author != nil {
+		query = query.Where("user_id = ?", author.ID)
+	} else {
+		query = query.Where("user_id IS NULL AND ip = ?", c.ClientIP())
+	}
+	var last models.Comment
+	if err := query.Order("created_at DESC").First(&last).Error; err != nil {
+		return true
+	}
+	wait := time.Until(last.CreatedAt.Add(time.Duration(post.SlowModeSeconds) * time.Second))
+	if wait > 0 {
+		retryAfter := int(wait.Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       "Slow mode is on for this post",
+			"code":        "slow_mode",
+			"retry_after": retryAfter,
+		})
+		return false
+	}
+	return true
+}
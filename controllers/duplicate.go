@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// nearDuplicateCandidates caps how many recent items a new one is compared
+// against.
+const nearDuplicateCandidates = 5000
+
+// checkDuplicate fingerprints new content destined for table. It responds
+// with 409 and returns ok=false when the same author, or for guests the same
+// IP, already posted the same text within the duplicate window.
+// nearDuplicate is set when a similar text from anyone appears in rows
+// matched by elsewhere, e.g. other posts. Moderators and admins are not
+// checked.
+func checkDuplicate(c *gin.Context, table string, author *models.User, content string, elsewhere func(*gorm.DB) *gorm.DB) (contentHash string, simHash int64, nearDuplicate, ok bool) {
+	contentHash = utils.ContentHash(content)
+	simHash = int64(utils.SimHash(content))
+	if author != nil && author.Role != models.RoleUser {
+		return contentHash, simHash, false, true
+	}
+	since := time.Now().Add(-config.AppConfig.DuplicateWindow)
+	recent := utils.GetDB().Table(table).Where("deleted_at IS NULL AND created_at > ?", since)
+	var exact int64
+	sameSource := recent.Session(&gorm.Session{}).Where("content_hash = ?", contentHash)
+	// Signed-in users are matched by account only: an IP can be shared by
+	// many unrelated people behind NAT.
+	if author != nil {
+		sameSource = sameSource.Where("user_id = ?", author.ID)
+	} else {
+		sameSource = sameSource.Where("user_id IS NULL AND ip = ?", c.ClientIP())
+	}
+	sameSource.Count(&exact)
+	if exact > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already posted this recently"})
+		return contentHash, simHash, false, false
+	}
+	if simHash == 0 {
+		return contentHash, simHash, false, true
+	}
+	var candidates []int64
+	recent.Session(&gorm.Session{}).Scopes(elsewhere).
+		Where("sim_hash <> 0").
+		Order("created_at DESC").
+		Limit(nearDuplicateCandidates).
+		Pluck("sim_hash", &candidates)
+	for _, candidate := range candidates {
+		if utils.HammingDistance(uint64(candidate), uint64(simHash)) <= config.AppConfig.DuplicateMaxDistance {
+			return contentHash, simHash, true, true
+		}
+	}
+	return contentHash, simHash, false, true
+}
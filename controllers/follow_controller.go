@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+func FollowUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if target.ID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot follow yourself"})
+		return
+	}
+	if isBlockedBy(target.ID, userID.(uint)) || isBlockedBy(userID.(uint), target.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You cannot follow this user"})
+		return
+	}
+	follow := models.Follow{FollowerID: userID.(uint), FolloweeID: target.ID}
+	if err := utils.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&follow).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to follow user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Following " + target.Username})
+}
+
+func UnfollowUser(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	target, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	if err := utils.GetDB().Where("follower_id = ? AND followee_id = ?", userID, target.ID).Delete(&models.Follow{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unfollow user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Unfollowed " + target.Username})
+}
+
+// GetFeed returns posts by followed users, newest first. It is paginated with
+// an opaque cursor rather than page numbers so new posts never shift pages.
+func GetFeed(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	_, pageSize, _ := paginationParams(c)
+	// For each followee the LATERAL subquery reads at most one page from
+	// idx_posts_user_created_id, newest first, and the outer query merges those
+	// pages. The work grows with the number of followees times the page size,
+	// not with how many posts they have ever written.
+	latest := utils.GetDB().Model(&models.Post{}).
+		Where("posts.user_id = follows.followee_id").
+		Scopes(visibleContent(c, "posts"))
+	if cursor := c.Query("cursor"); cursor != "" {
+		createdAt, id, err := utils.DecodeCursor(cursor)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		latest = latest.Where("(posts.created_at, posts.id) < (?, ?)", createdAt, id)
+	}
+	latest = latest.Order("posts.created_at DESC, posts.id DESC").Limit(pageSize + 1)
+	// The subquery already excludes deleted posts; follows has no deleted_at.
+	query := utils.GetDB().Unscoped().Table("follows").
+		Select("posts.*").
+		Joins("CROSS JOIN LATERAL (?) AS posts", latest).
+		Where("follows.follower_id = ?", userID).
+		Scopes(excludeMuted(c, "follows.followee_id"))
+	var posts []models.Post
+	if err := query.Order("posts.created_at DESC, posts.id DESC").Limit(pageSize + 1).Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+		return
+	}
+	var nextCursor *string
+	if len(posts) > pageSize {
+		posts = posts[:pageSize]
+		last := posts[len(posts)-1]
+		cursor := utils.EncodeCursor(last.CreatedAt, last.ID)
+		nextCursor = &cursor
+	}
+	resp := []gin.H{}
+	for _, post := range posts {
+		resp = append(resp, postResponse(post))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"posts":       resp,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+var errIPAlreadyBanned = errors.New("IP address or range already banned")
+
+type IPBanRequest struct {
+	CIDR      string     `json:"cidr" binding:"required,max=50"`
+	Reason    string     `json:"reason" binding:"max=500"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func ListIPBans(c *gin.Context) {
+	query := utils.GetDB().Order("id ASC")
+	if c.Query("active") == "true" {
+		query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	}
+	var bans []models.IPBan
+	if err := query.Find(&bans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch IP bans"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ip_bans": bans})
+}
+
+func CreateIPBan(c *gin.Context) {
+	var req IPBanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	prefix, err := utils.ParseIPBanTarget(req.CIDR)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cidr must be an IP address or CIDR range"})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	if addr, err := netip.ParseAddr(c.ClientIP()); err == nil && prefix.Contains(addr.Unmap()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot ban your own IP address"})
+		return
+	}
+	ban := models.IPBan{
+		CIDR:      prefix.String(),
+		Reason:    req.Reason,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: c.GetUint("userID"),
+	}
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		// An expired ban for the same range is reused rather than blocking
+		// the new one on the unique index.
+		var expired models.IPBan
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("cidr = ?", ban.CIDR).First(&expired).Error
+		if err == nil {
+			if expired.ExpiresAt == nil || expired.ExpiresAt.After(time.Now()) {
+				return errIPAlreadyBanned
+			}
+			ban.ID = expired.ID
+			ban.CreatedAt = time.Now()
+			if err := tx.Save(&ban).Error; err != nil {
+				return err
+			}
+			return recordModeration(tx, c, "ip_ban.create", auditTargetIPBan, ban.ID, expired, ban, req.Reason)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err := tx.Create(&ban).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "ip_ban.create", auditTargetIPBan, ban.ID, nil, ban, req.Reason)
+	})
+	if errors.Is(err, errIPAlreadyBanned) || utils.IsDuplicateKey(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "This IP address or range is already banned"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create IP ban"})
+		return
+	}
+	utils.InvalidateIPBans()
+	c.JSON(http.StatusCreated, ban)
+}
+
+func DeleteIPBan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid IP ban ID"})
+		return
+	}
+	var ban models.IPBan
+	if err := utils.GetDB().First(&ban, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "IP ban not found"})
+		return
+	}
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ban).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "ip_ban.delete", auditTargetIPBan, ban.ID, ban, nil, c.Query("reason"))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete IP ban"})
+		return
+	}
+	utils.InvalidateIPBans()
+	c.JSON(http.StatusOK, gin.H{"message": "IP ban removed"})
+}
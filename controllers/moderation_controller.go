@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type ModerationDecisionRequest struct {
+	PostIDs    []uint `json:"post_ids"`
+	CommentIDs []uint `json:"comment_ids"`
+	Spam       bool   `json:"spam"`
+	Reason     string `json:"reason" binding:"max=500"`
+}
+
+// createdStatus answers 202 Accepted for content held for moderation and
+// 201 Created for content that is published immediately.
+func createdStatus(state string) int {
+	if state == models.ModerationPending {
+		return http.StatusAccepted
+	}
+	return http.StatusCreated
+}
+
+// editedModerationState applies the pre-approval rules to edited content.
+// An edit can send approved content back to the queue but never publishes
+// content that is pending, rejected or spam.
+func editedModerationState(author *models.User, current string, texts ...string) string {
+	if current != models.ModerationApproved {
+		return current
+	}
+	return utils.InitialModerationState(author, texts...)
+}
+
+// requeueEdited is the moderation_state update for an edit that needs
+// review. It only applies while the row is still approved, so a moderator
+// decision made since the content was loaded is kept.
+var requeueEdited = gorm.Expr("CASE WHEN moderation_state = ? THEN ? ELSE moderation_state END",
+	models.ModerationApproved, models.ModerationPending)
+
+// ModeratorDeletePost deletes anyone's post, with an optional ?reason= for
+// the audit log.
+func ModeratorDeletePost(c *gin.Context) {
+	moderatorDelete(c, models.ReportTargetPost, &models.Post{}, "Post")
+}
+
+// ModeratorDeleteComment deletes anyone's comment, with an optional ?reason=
+// for the audit log.
+func ModeratorDeleteComment(c *gin.Context) {
+	moderatorDelete(c, models.ReportTargetComment, &models.Comment{}, "Comment")
+}
+
+func moderatorDelete(c *gin.Context, targetType string, target interface{}, label string) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + targetType + " ID"})
+		return
+	}
+	if err := utils.GetDB().First(target, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": label + " not found"})
+		return
+	}
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(target).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, targetType+".delete", targetType, uint(id), target, nil, c.Query("reason"))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete " + targetType})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": label + " deleted"})
+}
+
+// GetModerationQueue lists posts and comments in a moderation state, oldest
+// first. state defaults to pending; type limits the queue to posts or comments.
+func GetModerationQueue(c *gin.Context) {
+	state := c.DefaultQuery("state", models.ModerationPending)
+	switch state {
+	case models.ModerationPending, models.ModerationApproved, models.ModerationRejected, models.ModerationSpam:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid moderation state"})
+		return
+	}
+	kind := c.Query("type")
+	page, pageSize, offset := paginationParams(c)
+	resp := gin.H{}
+	if kind == "" || kind == "posts" {
+		var posts []models.Post
+		var total int64
+		query := utils.GetDB().Model(&models.Post{}).Where("moderation_state = ?", state)
+		query.Count(&total)
+		if err := query.Order("created_at ASC").Limit(pageSize).Offset(offset).Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+			return
+		}
+		items := []gin.H{}
+		for _, post := range posts {
+			items = append(items, postResponse(post))
+		}
+		resp["posts"] = gin.H{"items": items, "pagination": paginationResponse(page, pageSize, total)}
+	}
+	if kind == "" || kind == "comments" {
+		var comments []models.Comment
+		var total int64
+		query := utils.GetDB().Model(&models.Comment{}).Where("moderation_state = ?", state)
+		query.Count(&total)
+		if err := query.Order("created_at ASC").Limit(pageSize).Offset(offset).Find(&comments).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch comments"})
+			return
+		}
+		items := []gin.H{}
+		for _, comment := range comments {
+			item := commentResponse(comment)
+			item["spam_score"] = comment.SpamScore
+			items = append(items, item)
+		}
+		resp["comments"] = gin.H{"items": items, "pagination": paginationResponse(page, pageSize, total)}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func ApproveContent(c *gin.Context) {
+	decideContent(c, models.ModerationApproved)
+}
+
+// RejectContent rejects the given items, or marks them as spam when spam is set.
+func RejectContent(c *gin.Context) {
+	decideContent(c, models.ModerationRejected)
+}
+
+func decideContent(c *gin.Context, state string) {
+	var req ModerationDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.PostIDs) == 0 && len(req.CommentIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post_ids or comment_ids is required"})
+		return
+	}
+	if state == models.ModerationRejected && req.Spam {
+		state = models.ModerationSpam
+	}
+	moderatorID := c.GetUint("userID")
+	now := time.Now()
+	updates := map[string]interface{}{
+		"moderation_state": state,
+		"moderated_by":     moderatorID,
+		"moderated_at":     now,
+		// A decision replaces any hide from reports.
+		"hidden_by_reports": false,
+	}
+	var postsUpdated, commentsUpdated int64
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if len(req.PostIDs) > 0 {
+			var posts []models.Post
+			if err := tx.Where("id IN ?", req.PostIDs).Find(&posts).Error; err != nil {
+				return err
+			}
+			res := tx.Model(&models.Post{}).Where("id IN ?", req.PostIDs).Updates(updates)
+			if res.Error != nil {
+				return res.Error
+			}
+			postsUpdated = res.RowsAffected
+			for _, before := range posts {
+				after := before
+				after.ModerationState, after.ModeratedBy, after.ModeratedAt = state, &moderatorID, &now
+				if err := recordModeration(tx, c, "post."+state, models.ReportTargetPost, before.ID, before, after, req.Reason); err != nil {
+					return err
+				}
+			}
+		}
+		if len(req.CommentIDs) > 0 {
+			var comments []models.Comment
+			if err := tx.Where("id IN ?", req.CommentIDs).Find(&comments).Error; err != nil {
+				return err
+			}
+			res := tx.Model(&models.Comment{}).Where("id IN ?", req.CommentIDs).Updates(updates)
+			if res.Error != nil {
+				return res.Error
+			}
+			commentsUpdated = res.RowsAffected
+			for _, before := range comments {
+				after := before
+				after.ModerationState, after.ModeratedBy, after.ModeratedAt = state, &moderatorID, &now
+				if err := recordModeration(tx, c, "comment."+state, models.ReportTargetComment, before.ID, before, after, req.Reason); err != nil {
+					return err
+				}
+			}
+			if err := trainSpamClassifier(tx, req.CommentIDs, state); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update moderation state"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"moderation_state": state,
+		"posts_updated":    postsUpdated,
+		"comments_updated": commentsUpdated,
+	})
+}
+
+// trainSpamClassifier teaches the spam classifier from a moderator decision:
+// approved comments are ham and spam comments are spam. Rejections for other
+// reasons leave the classifier alone.
+func trainSpamClassifier(tx *gorm.DB, commentIDs []uint, state string) error {
+	label := ""
+	switch state {
+	case models.ModerationApproved:
+		label = models.SpamLabelHam
+	case models.ModerationSpam:
+		label = models.SpamLabelSpam
+	default:
+		return nil
+	}
+	var comments []models.Comment
+	if err := tx.Select("id", "content", "spam_label").Where("id IN ?", commentIDs).Find(&comments).Error; err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if err := utils.TrainSpam(tx, comment.Content, comment.SpamLabel, label); err != nil {
+			return err
+		}
+		if err := tx.Model(&comment).UpdateColumn("spam_label", label).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// paginationParams reads the page and page_size query parameters, falling
+// back to the first page of defaultPageSize and capping at maxPageSize.
+func paginationParams(c *gin.Context) (page, pageSize, offset int) {
+	page = 1
+	pageSize = defaultPageSize
+	if p := c.Query("page"); p != "" {
+		if v, err := strconv.Atoi(p); err == nil && v > 0 {
+			page = v
+		}
+	}
+	if ps := c.Query("page_size"); ps != "" {
+		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
+			if v > maxPageSize {
+				pageSize = maxPageSize
+			} else {
+				pageSize = v
+			}
+		}
+	}
+	return page, pageSize, (page - 1) * pageSize
+}
+
+func paginationResponse(page, pageSize int, total int64) gin.H {
+	return gin.H{
+		"page":        page,
+		"page_size":   pageSize,
+		"total":       total,
+		"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+var errPinLimit = errors.New("pinned comment limit reached")
+
+type PinPostRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// PinPost pins a post to the top of the post list, optionally until
+// expires_at. Pinning an already pinned post replaces its expiry.
+func PinPost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	var req PinPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+		return
+	}
+	var post models.Post
+	if err := utils.GetDB().First(&post, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	before := post
+	moderatorID := c.GetUint("userID")
+	now := time.Now()
+	post.PinnedAt = &now
+	post.PinnedUntil = req.ExpiresAt
+	post.PinnedBy = &moderatorID
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Select("pinned_at", "pinned_until", "pinned_by").Updates(&post).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "post.pin", models.ReportTargetPost, post.ID, before, post, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pin post"})
+		return
+	}
+	c.JSON(http.StatusOK, postResponse(post))
+}
+
+func UnpinPost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	var post models.Post
+	if err := utils.GetDB().First(&post, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	before := post
+	post.PinnedAt = nil
+	post.PinnedUntil = nil
+	post.PinnedBy = nil
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&post).Select("pinned_at", "pinned_until", "pinned_by").Updates(&post).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "post.unpin", models.ReportTargetPost, post.ID, before, post, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpin post"})
+		return
+	}
+	c.JSON(http.StatusOK, postResponse(post))
+}
+
+// PinComment lets a post's owner pin a comment on it, up to
+// MAX_PINNED_COMMENTS per post.
+func PinComment(c *gin.Context) {
+	comment, ok := findOwnPostComment(c)
+	if !ok {
+		return
+	}
+	if comment.PinnedAt != nil {
+		c.JSON(http.StatusOK, commentResponse(comment))
+		return
+	}
+	if comment.ModerationState != models.ModerationApproved {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only approved comments can be pinned"})
+		return
+	}
+	limit := config.AppConfig.MaxPinnedComments
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		// Lock the post so concurrent pins cannot exceed the limit.
+		var post models.Post
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, comment.PostID).Error; err != nil {
+			return err
+		}
+		// Pinned comments hidden by moderation since do not use up a slot.
+		var pinned int64
+		tx.Model(&models.Comment{}).
+			Where("post_id = ? AND pinned_at IS NOT NULL AND moderation_state = ?", comment.PostID, models.ModerationApproved).
+			Count(&pinned)
+		if pinned >= int64(limit) {
+			return errPinLimit
+		}
+		now := time.Now()
+		comment.PinnedAt = &now
+		return tx.Model(&comment).UpdateColumn("pinned_at", now).Error
+	})
+	if errors.Is(err, errPinLimit) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A post can have at most %d pinned comments", limit)})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pin comment"})
+		return
+	}
+	c.JSON(http.StatusOK, commentResponse(comment))
+}
+
+func UnpinComment(c *gin.Context) {
+	comment, ok := findOwnPostComment(c)
+	if !ok {
+		return
+	}
+	if err := utils.GetDB().Model(&comment).UpdateColumn("pinned_at", nil).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unpin comment"})
+		return
+	}
+	comment.PinnedAt = nil
+	c.JSON(http.StatusOK, commentResponse(comment))
+}
+
+// findOwnPostComment loads the :id comment and checks that it is on a post
+// owned by the current user.
+func findOwnPostComment(c *gin.Context) (models.Comment, bool) {
+	var comment models.Comment
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return comment, false
+	}
+	if err := utils.GetDB().First(&comment, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return comment, false
+	}
+	var post models.Post
+	if err := utils.GetDB().First(&post, comment.PostID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return comment, false
+	}
+	if post.UserID == nil || *post.UserID != c.GetUint("userID") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the post owner can pin comments"})
+		return comment, false
+	}
+	return comment, true
+}
+
+// pinnedPostsOrder sorts currently pinned posts, most recently pinned first,
+// ahead of the rest of a listing. Apply it before the listing's own order.
+const pinnedPostsOrder = "CASE WHEN posts.pinned_until IS NULL OR posts.pinned_until > NOW() THEN posts.pinned_at END DESC NULLS LAST"
+
+// pinnedCommentsOrder sorts pinned comments, in the order they were pinned,
+// ahead of the rest of a thread.
+const pinnedCommentsOrder = "comments.pinned_at ASC NULLS LAST"
+
+// pinnedCommentsFirst orders preloaded comments: pinned first, then oldest
+// first.
+func pinnedCommentsFirst(db *gorm.DB) *gorm.DB {
+	return db.Order(pinnedCommentsOrder).Order("comments.created_at ASC")
+}
+
+// isPinned reports whether post is pinned right now.
+func isPinned(post *models.Post) bool {
+	return post.PinnedAt != nil && (post.PinnedUntil == nil || post.PinnedUntil.After(time.Now()))
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"post-comments-api/models"
 	"post-comments-api/utils"
 )
@@ -31,18 +32,32 @@ func CreatePost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	uid := userID.(uint)
+	var author models.User
+	if err := utils.GetDB().First(&author, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
 	post := models.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		UserID:  &uid,
-		Author:  req.Author,
+		Title:           req.Title,
+		Content:         req.Content,
+		UserID:          &author.ID,
+		Author:          req.Author,
+		ModerationState: utils.InitialModerationState(&author, req.Title, req.Content),
+	}
+	if !checkTrust(c, &author, true, post.Title, post.Content) {
+		return
+	}
+	if !filterWords(c, &post.ModerationState, &post.Title, &post.Content) {
+		return
+	}
+	if !fingerprintPost(c, &post, &author) {
+		return
 	}
 	if err := utils.GetDB().Create(&post).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
-	c.JSON(http.StatusCreated, post)
+	c.JSON(createdStatus(post.ModerationState), postResponse(post))
 }
 
 func CreatePostPublic(c *gin.Context) {
@@ -52,67 +67,42 @@ func CreatePostPublic(c *gin.Context) {
 		return
 	}
 	post := models.Post{
-		Title:   req.Title,
-		Content: req.Content,
-		Author:  req.Author,
+		Title:           req.Title,
+		Content:         req.Content,
+		Author:          req.Author,
+		ModerationState: utils.InitialModerationState(nil, req.Title, req.Content),
+	}
+	if !filterWords(c, &post.ModerationState, &post.Title, &post.Content) {
+		return
+	}
+	if !fingerprintPost(c, &post, nil) {
+		return
 	}
 	if err := utils.GetDB().Create(&post).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create post"})
 		return
 	}
-	c.JSON(http.StatusCreated, post)
+	c.JSON(createdStatus(post.ModerationState), postResponse(post))
 }
 
 func GetPosts(c *gin.Context) {
-	// Pagination params
-	page := 1
-	pageSize := 10
-	maxPageSize := 50
-	if p := c.Query("page"); p != "" {
-		if v, err := strconv.Atoi(p); err == nil && v > 0 {
-			page = v
-		}
-	}
-	if ps := c.Query("page_size"); ps != "" {
-		if v, err := strconv.Atoi(ps); err == nil && v > 0 {
-			if v > maxPageSize {
-				pageSize = maxPageSize
-			} else {
-				pageSize = v
-			}
-		}
-	}
-	offset := (page - 1) * pageSize
+	page, pageSize, offset := paginationParams(c)
 	var posts []models.Post
 	var total int64
-	utils.GetDB().Model(&models.Post{}).Count(&total)
-	if err := utils.GetDB().Preload("Comments").Limit(pageSize).Offset(offset).Order("created_at DESC").Find(&posts).Error; err != nil {
+	utils.GetDB().Model(&models.Post{}).Scopes(visibleContent(c, "posts")).Count(&total)
+	if err := utils.GetDB().Scopes(visibleContent(c, "posts")).Preload("Comments", visibleContent(c, "comments"), pinnedCommentsFirst).Limit(pageSize).Offset(offset).Order(pinnedPostsOrder).Order("created_at DESC").Find(&posts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
 		return
 	}
 	var resp []gin.H
 	for _, post := range posts {
-		htmlContent, _ := utils.RenderMarkdown(post.Content)
-		resp = append(resp, gin.H{
-			"id": post.ID,
-			"user_id": post.UserID,
-			"author": post.Author,
-			"title": post.Title,
-			"content": post.Content,
-			"html_content": htmlContent,
-			"created_at": post.CreatedAt,
-			"updated_at": post.UpdatedAt,
-			"comments": post.Comments,
-		})
+		item := postResponse(post)
+		item["comments"] = commentResponses(post.Comments)
+		resp = append(resp, item)
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"posts": resp,
-		"pagination": gin.H{
-			"page": page,
-			"page_size": pageSize,
-			"total": total,
-			"total_pages": (total + int64(pageSize) - 1) / int64(pageSize),
-		},
+		"pagination": paginationResponse(page, pageSize, total),
 	})
 }
 
@@ -123,22 +113,76 @@ func GetPost(c *gin.Context) {
 		return
 	}
 	var post models.Post
-	if err := utils.GetDB().Preload("Comments").First(&post, id).Error; err != nil {
+	if err := utils.GetDB().Preload("Comments", visibleContent(c, "comments"), pinnedCommentsFirst).First(&post, id).Error; err != nil || !canView(c, post.ModerationState, post.UserID) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
-	htmlContent, _ := utils.RenderMarkdown(post.Content)
+	resp := postResponse(post)
+	resp["comments"] = commentResponses(post.Comments)
+	c.JSON(http.StatusOK, resp)
+}
+
+func GetUserPosts(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+	page, pageSize, offset := paginationParams(c)
+	var posts []models.Post
+	var total int64
+	query := utils.GetDB().Model(&models.Post{}).Where("user_id = ?", user.ID).Scopes(visibleContent(c, "posts"))
+	query.Count(&total)
+	if err := query.Limit(pageSize).Offset(offset).Order("created_at DESC").Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	resp := []gin.H{}
+	for _, post := range posts {
+		resp = append(resp, postResponse(post))
+	}
 	c.JSON(http.StatusOK, gin.H{
+		"posts": resp,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+// fingerprintPost records the post's fingerprints and IP, rejecting exact
+// repeats and queueing near-duplicates of other posts.
+func fingerprintPost(c *gin.Context, post *models.Post, author *models.User) bool {
+	anyPost := func(db *gorm.DB) *gorm.DB { return db }
+	hash, simHash, near, ok := checkDuplicate(c, "posts", author, post.Content, anyPost)
+	if !ok {
+		return false
+	}
+	post.ContentHash = hash
+	post.SimHash = simHash
+	post.IP = c.ClientIP()
+	if near && post.ModerationState == models.ModerationApproved {
+		post.ModerationState = models.ModerationPending
+	}
+	return true
+}
+
+func postResponse(post models.Post) gin.H {
+	htmlContent, _ := utils.RenderMarkdown(post.Content)
+	return gin.H{
 		"id": post.ID,
 		"user_id": post.UserID,
 		"author": post.Author,
+		"avatar_url": utils.AvatarURL(post.UserID, post.Author),
 		"title": post.Title,
 		"content": post.Content,
 		"html_content": htmlContent,
+		"moderation_state": post.ModerationState,
+		"comments_locked": post.CommentsLocked,
+		"comments_close_at": commentsCloseAt(&post),
+		"comments_open": commentsOpen(&post),
+		"slow_mode_seconds": post.SlowModeSeconds,
+		"pinned": isPinned(&post),
+		"pinned_until": post.PinnedUntil,
 		"created_at": post.CreatedAt,
 		"updated_at": post.UpdatedAt,
-		"comments": post.Comments,
-	})
+	}
 }
 
 func UpdatePost(c *gin.Context) {
@@ -172,11 +216,38 @@ func UpdatePost(c *gin.Context) {
 	if req.Content != "" {
 		post.Content = req.Content
 	}
-	if err := utils.GetDB().Save(&post).Error; err != nil {
+	var author models.User
+	if err := utils.GetDB().First(&author, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return
+	}
+	if !checkTrust(c, &author, false, post.Title, post.Content) {
+		return
+	}
+	state := editedModerationState(&author, post.ModerationState, post.Title, post.Content)
+	if !filterWords(c, &state, &post.Title, &post.Content) {
+		return
+	}
+	// Only the edited columns are written so moderator changes made in the
+	// meantime, such as a rejection, are not undone.
+	updates := map[string]interface{}{
+		"title":        post.Title,
+		"content":      post.Content,
+		"content_hash": utils.ContentHash(post.Content),
+		"sim_hash":     int64(utils.SimHash(post.Content)),
+	}
+	if state != post.ModerationState {
+		updates["moderation_state"] = requeueEdited
+	}
+	if err := utils.GetDB().Model(&post).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+	if err := utils.GetDB().First(&post, post.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
 		return
 	}
-	c.JSON(http.StatusOK, post)
+	c.JSON(http.StatusOK, postResponse(post))
 }
 
 func DeletePost(c *gin.Context) {
@@ -0,0 +1,268 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type CreateReportRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam harassment hate violence sexual misinformation other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+type ResolveReportsRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=post comment"`
+	TargetID   uint   `json:"target_id" binding:"required"`
+	Action     string `json:"action" binding:"required,oneof=dismiss reject delete"`
+	Reason     string `json:"reason" binding:"max=500"`
+}
+
+// Report resolution actions. dismiss keeps (and re-approves) the content,
+// reject removes it from view and delete soft-deletes it.
+const (
+	reportActionDismiss = "dismiss"
+	reportActionReject  = "reject"
+)
+
+func ReportPost(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+	var post models.Post
+	if err := utils.GetDB().First(&post, id).Error; err != nil || !canView(c, post.ModerationState, post.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	createReport(c, models.ReportTargetPost, post.ID, post.UserID)
+}
+
+func ReportComment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid comment ID"})
+		return
+	}
+	var comment models.Comment
+	if err := utils.GetDB().First(&comment, id).Error; err != nil || !canView(c, comment.ModerationState, comment.UserID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return
+	}
+	createReport(c, models.ReportTargetComment, comment.ID, comment.UserID)
+}
+
+func createReport(c *gin.Context, targetType string, targetID uint, authorID *uint) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	if authorID != nil && *authorID == userID.(uint) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report your own content"})
+		return
+	}
+	var req CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	report := models.Report{
+		TargetType: targetType,
+		TargetID:   targetID,
+		ReporterID: userID.(uint),
+		Reason:     req.Reason,
+		Details:    req.Details,
+		Status:     models.ReportOpen,
+	}
+	res := utils.GetDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&report)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already reported this"})
+		return
+	}
+	hideReportedContent(targetType, targetID)
+	c.JSON(http.StatusCreated, report)
+}
+
+// hideReportedContent sends approved content back to the moderation queue
+// once its open reports reach the configured threshold. The row is flagged so
+// dismissing the reports can publish it again.
+func hideReportedContent(targetType string, targetID uint) {
+	threshold := config.AppConfig.ReportHideThreshold
+	if threshold <= 0 {
+		return
+	}
+	var open int64
+	utils.GetDB().Model(&models.Report{}).
+		Where("target_type = ? AND target_id = ? AND status = ?", targetType, targetID, models.ReportOpen).
+		Count(&open)
+	if open < int64(threshold) {
+		return
+	}
+	utils.GetDB().Model(reportTargetModel(targetType)).
+		Where("id = ? AND moderation_state = ?", targetID, models.ModerationApproved).
+		Updates(map[string]interface{}{"moderation_state": models.ModerationPending, "hidden_by_reports": true})
+}
+
+func reportTargetModel(targetType string) interface{} {
+	if targetType == models.ReportTargetComment {
+		return &models.Comment{}
+	}
+	return &models.Post{}
+}
+
+type reportedTarget struct {
+	TargetType     string
+	TargetID       uint
+	ReportCount    int64
+	LastReportedAt time.Time
+}
+
+// ListReports returns reported posts and comments, most reported first, with
+// the individual reports and the reported content.
+func ListReports(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ReportOpen)
+	page, pageSize, offset := paginationParams(c)
+	filter := func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&models.Report{}).Where("status = ?", status)
+		if targetType := c.Query("type"); targetType != "" {
+			db = db.Where("target_type = ?", targetType)
+		}
+		return db
+	}
+	var total int64
+	grouped := utils.GetDB().Scopes(filter).Select("target_type, target_id").Group("target_type, target_id")
+	utils.GetDB().Table("(?) AS reported", grouped).Count(&total)
+	var targets []reportedTarget
+	if err := utils.GetDB().Scopes(filter).Select("target_type, target_id, COUNT(*) AS report_count, MAX(created_at) AS last_reported_at").
+		Group("target_type, target_id").
+		Order("report_count DESC, last_reported_at DESC").
+		Limit(pageSize).Offset(offset).
+		Scan(&targets).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+	items := []gin.H{}
+	for _, target := range targets {
+		var reports []models.Report
+		utils.GetDB().Where("target_type = ? AND target_id = ? AND status = ?", target.TargetType, target.TargetID, status).
+			Order("created_at ASC").Find(&reports)
+		item := gin.H{
+			"target_type":      target.TargetType,
+			"target_id":        target.TargetID,
+			"report_count":     target.ReportCount,
+			"last_reported_at": target.LastReportedAt,
+			"reports":          reports,
+		}
+		if target.TargetType == models.ReportTargetComment {
+			var comment models.Comment
+			if err := utils.GetDB().Unscoped().First(&comment, target.TargetID).Error; err == nil {
+				item["content"] = commentResponse(comment)
+			}
+		} else {
+			var post models.Post
+			if err := utils.GetDB().Unscoped().First(&post, target.TargetID).Error; err == nil {
+				item["content"] = postResponse(post)
+			}
+		}
+		items = append(items, item)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"reports":    items,
+		"pagination": paginationResponse(page, pageSize, total),
+	})
+}
+
+// ResolveReports closes every open report on a post or comment and applies
+// the chosen action to the content.
+func ResolveReports(c *gin.Context) {
+	var req ResolveReportsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	status := models.ReportResolved
+	var contentUpdate map[string]interface{}
+	moderatorID := c.GetUint("userID")
+	now := time.Now()
+	switch req.Action {
+	case reportActionDismiss:
+		status = models.ReportDismissed
+		// Only undo the hide done by hideReportedContent; content held by
+		// pre-approval or a moderator decision keeps its state.
+		contentUpdate = map[string]interface{}{
+			"moderation_state": gorm.Expr("CASE WHEN hidden_by_reports AND moderation_state = ? THEN ? ELSE moderation_state END",
+				models.ModerationPending, models.ModerationApproved),
+			"hidden_by_reports": false,
+		}
+	case reportActionReject:
+		contentUpdate = map[string]interface{}{"moderation_state": models.ModerationRejected, "moderated_by": moderatorID, "moderated_at": now, "hidden_by_reports": false}
+	}
+	var resolved int64
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		before := reportTargetModel(req.TargetType)
+		if err := tx.Unscoped().First(before, req.TargetID).Error; err != nil {
+			return err
+		}
+		target := tx.Model(reportTargetModel(req.TargetType)).Where("id = ?", req.TargetID)
+		var err error
+		if contentUpdate != nil {
+			// Unscoped so reports on content its author has since deleted can
+			// still be closed.
+			err = target.Unscoped().Updates(contentUpdate).Error
+		} else {
+			err = target.Delete(reportTargetModel(req.TargetType)).Error
+		}
+		if err != nil {
+			return err
+		}
+		// A deleted target has no after snapshot.
+		var after interface{}
+		if contentUpdate != nil {
+			after = reportTargetModel(req.TargetType)
+			if err := tx.Unscoped().First(after, req.TargetID).Error; err != nil {
+				return err
+			}
+		}
+		if err := recordModeration(tx, c, "report."+req.Action, req.TargetType, req.TargetID, before, after, req.Reason); err != nil {
+			return err
+		}
+		res := tx.Model(&models.Report{}).
+			Where("target_type = ? AND target_id = ? AND status = ?", req.TargetType, req.TargetID, models.ReportOpen).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"resolution":  req.Action,
+				"resolved_by": moderatorID,
+				"resolved_at": now,
+			})
+		resolved = res.RowsAffected
+		return res.Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reported content not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve reports"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"target_type":      req.TargetType,
+		"target_id":        req.TargetID,
+		"action":           req.Action,
+		"reports_resolved": resolved,
+	})
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+func setupReportTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Post{}, &models.Comment{}, &models.Report{}, &models.ModerationAudit{}); err != nil {
+		t.Fatal(err)
+	}
+	utils.SetDB(db)
+	t.Cleanup(func() { utils.SetDB(nil) })
+	return db
+}
+
+func resolveReports(t *testing.T, body ResolveReportsRequest) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	payload, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/moderation/reports/resolve", bytes.NewReader(payload))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("userID", uint(99))
+	ResolveReports(c)
+	return w
+}
+
+func TestResolveReportsOnDeletedPost(t *testing.T) {
+	tests := []struct {
+		action     string
+		wantStatus string
+		wantState  string
+	}{
+		{reportActionDismiss, models.ReportDismissed, models.ModerationApproved},
+		{reportActionReject, models.ReportResolved, models.ModerationRejected},
+		{"delete", models.ReportResolved, models.ModerationPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			db := setupReportTestDB(t)
+			post := models.Post{Title: "t", Content: "c", ModerationState: models.ModerationPending, HiddenByReports: true}
+			if err := db.Create(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&models.Report{TargetType: models.ReportTargetPost, TargetID: post.ID, ReporterID: 1, Reason: "spam", Status: models.ReportOpen}).Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Delete(&post).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			w := resolveReports(t, ResolveReportsRequest{TargetType: models.ReportTargetPost, TargetID: post.ID, Action: tt.action})
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+			var report models.Report
+			db.First(&report)
+			if report.Status != tt.wantStatus || report.Resolution != tt.action {
+				t.Errorf("report = %s/%s, want %s/%s", report.Status, report.Resolution, tt.wantStatus, tt.action)
+			}
+			var after models.Post
+			db.Unscoped().First(&after, post.ID)
+			if after.ModerationState != tt.wantState || !after.DeletedAt.Valid {
+				t.Errorf("post state = %s (deleted %v), want %s (deleted)", after.ModerationState, after.DeletedAt.Valid, tt.wantState)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+func ListSessions(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var sessions []models.Session
+	if err := utils.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ? AND impersonator_id IS NULL", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+		return
+	}
+	currentID := c.GetUint("sessionID")
+	resp := []gin.H{}
+	for _, s := range sessions {
+		resp = append(resp, gin.H{
+			"id":           s.ID,
+			"user_agent":   s.UserAgent,
+			"ip":           s.IP,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.ID == currentID,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": resp})
+}
+
+func RevokeSession(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+	var session models.Session
+	if err := utils.GetDB().Where("user_id = ? AND revoked_at IS NULL", userID).First(&session, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err := utils.GetDB().Model(&session).Update("revoked_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// respondWithSession records a login session for the request and returns an
+// access token bound to it, either in the body or, when useCookie is set, as
+// an HttpOnly cookie alongside a CSRF token.
+func respondWithSession(c *gin.Context, user *models.User, useCookie bool) {
+	session, err := utils.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+	token, err := utils.GenerateJWT(user.ID, session.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+	if useCookie {
+		csrf := utils.CSRFToken(session.ID)
+		utils.SetSessionCookies(c.Writer, token, csrf)
+		c.JSON(http.StatusOK, gin.H{"csrf_token": csrf})
+		return
+	}
+	c.JSON(http.StatusOK, AuthResponse{Token: token})
+}
+
+func Logout(c *gin.Context) {
+	if sessionID := c.GetUint("sessionID"); sessionID != 0 {
+		if err := utils.GetDB().Model(&models.Session{}).Where("id = ?", sessionID).Update("revoked_at", time.Now()).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign out"})
+			return
+		}
+	}
+	utils.ClearSessionCookies(c.Writer)
+	c.JSON(http.StatusOK, gin.H{"message": "Signed out"})
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// checkTrust applies the author's trust level to new or edited content:
+// new accounts cannot post links, and lower levels may only create a limited
+// number of posts and comments per hour. It responds and returns false when
+// the content is refused.
+func checkTrust(c *gin.Context, author *models.User, creating bool, texts ...string) bool {
+	level := utils.TrustLevel(author)
+	if level == models.TrustLevelNew {
+		for _, text := range texts {
+			if utils.ContainsLink(text) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "New accounts cannot post links yet", "trust_level": level})
+				return false
+			}
+		}
+	}
+	limit := utils.TrustHourlyLimit(level)
+	if !creating || limit == 0 {
+		return true
+	}
+	since := time.Now().Add(-time.Hour)
+	var posts, comments int64
+	// Deleted content still counts so deleting does not reset the limit.
+	utils.GetDB().Unscoped().Model(&models.Post{}).Where("user_id = ? AND created_at > ?", author.ID, since).Count(&posts)
+	utils.GetDB().Unscoped().Model(&models.Comment{}).Where("user_id = ? AND created_at > ?", author.ID, since).Count(&comments)
+	if posts+comments >= int64(limit) {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       fmt.Sprintf("You can create %d posts and comments per hour at your trust level", limit),
+			"trust_level": level,
+		})
+		return false
+	}
+	return true
+}
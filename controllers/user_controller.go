@@ -2,22 +2,38 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
 	"post-comments-api/models"
 	"post-comments-api/utils"
 )
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,alphanum,min=3,max=32"`
-	Password string `json:"password" binding:"required,min=6,max=64"`
+	Password string `json:"password" binding:"required"`
+}
+
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Bio         *string `json:"bio" binding:"omitempty,max=500"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,http_url,max=500"`
+	Website     *string `json:"website" binding:"omitempty,http_url,max=255"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
 type LoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username  string `json:"username" binding:"required"`
+	Password  string `json:"password" binding:"required"`
+	UseCookie bool   `json:"use_cookie"`
 }
 
 type AuthResponse struct {
@@ -30,21 +46,24 @@ func Register(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if violations := utils.CheckPasswordPolicy(req.Password, req.Username); len(violations) > 0 {
+		respondPasswordPolicy(c, violations)
+		return
+	}
+	hash, err := utils.HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
 		return
 	}
-	user := models.User{Username: req.Username, Password: string(hash)}
+	user := models.User{Username: req.Username, Password: hash}
 	err = utils.GetDB().Create(&user).Error
 	if err != nil {
-		detail := err.Error()
-		if strings.Contains(detail, "duplicate key") || strings.Contains(detail, "UNIQUE constraint failed") {
+		if utils.IsDuplicateKey(err) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
 			return
 		}
 		// Return the real error for debugging
-		c.JSON(http.StatusBadRequest, gin.H{"error": detail})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully"})
@@ -56,21 +75,60 @@ func Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !checkLoginLockout(c, req.Username) {
+		return
+	}
 	var user models.User
-	if err := utils.GetDB().Where("username = ?", req.Username).First(&user).Error; err != nil {
+	lookupErr := utils.GetDB().Where("username = ?", req.Username).First(&user).Error
+	hash := dummyPasswordHash()
+	if lookupErr == nil {
+		hash = user.Password
+	}
+	ok, rehash, err := utils.VerifyPassword(hash, req.Password)
+	if !ok || err != nil || lookupErr != nil {
+		recordLoginFailure(c, req.Username, "invalid_credentials")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+	if rehash {
+		rehashPassword(&user, req.Password)
+	}
+	// Only reveal the suspension once the password is known to be correct.
+	if utils.IsSuspended(&user) {
+		respondSuspended(c, &user)
 		return
 	}
-	token, err := utils.GenerateJWT(user.ID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAChallengeJWT(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+			return
+		}
+		c.JSON(http.StatusOK, MFAChallengeResponse{MFARequired: true, MFAToken: mfaToken})
 		return
 	}
-	c.JSON(http.StatusOK, AuthResponse{Token: token})
+	utils.ClearLoginFailures(utils.GetDB(), utils.UsernameThrottleKey(user.Username))
+	respondWithSession(c, &user, req.UseCookie)
+}
+
+func respondSuspended(c *gin.Context, user *models.User) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":           "Account suspended",
+		"reason":          user.SuspensionReason,
+		"suspended_until": user.SuspendedUntil,
+	})
+}
+
+// rehashPassword upgrades a stored hash to the current algorithm and
+// parameters. Failures are logged but never block the login.
+func rehashPassword(user *models.User, password string) {
+	hash, err := utils.HashPassword(password)
+	if err == nil {
+		err = utils.GetDB().Model(user).Update("password", hash).Error
+	}
+	if err != nil {
+		log.Error().Err(err).Uint("user_id", user.ID).Msg("failed to rehash password")
+	}
 }
 
 func GetCurrentUser(c *gin.Context) {
@@ -84,5 +142,161 @@ func GetCurrentUser(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"id": user.ID, "username": user.Username})
+	resp := userProfile(user)
+	resp["role"] = user.Role
+	resp["totp_enabled"] = user.TOTPEnabled
+	resp["trust_level"] = utils.TrustLevel(&user)
+	resp["deletion_scheduled_at"] = user.DeletionScheduledAt
+	c.JSON(http.StatusOK, resp)
+}
+
+func UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	updates := map[string]interface{}{}
+	if req.DisplayName != nil {
+		updates["display_name"] = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Bio != nil {
+		updates["bio"] = *req.Bio
+	}
+	if req.AvatarURL != nil {
+		updates["avatar_url"] = *req.AvatarURL
+	}
+	if req.Website != nil {
+		updates["website"] = *req.Website
+	}
+	if len(updates) > 0 {
+		if err := utils.GetDB().Model(&user).Updates(updates).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, userProfile(user))
+}
+
+func GetUserProfile(c *gin.Context) {
+	var user models.User
+	if err := utils.GetDB().Where("username = ?", c.Param("user")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	// A shadow-banned user's content is hidden from everyone else, so
+	// counting it here would give the ban away.
+	var postCount, commentCount int64
+	if user.ShadowBannedAt == nil {
+		utils.GetDB().Model(&models.Post{}).Where("user_id = ? AND moderation_state = ?", user.ID, models.ModerationApproved).Count(&postCount)
+		utils.GetDB().Model(&models.Comment{}).Where("user_id = ? AND moderation_state = ?", user.ID, models.ModerationApproved).Count(&commentCount)
+	}
+	resp := userProfile(user)
+	var followerCount, followingCount int64
+	utils.GetDB().Model(&models.Follow{}).Where("followee_id = ?", user.ID).Count(&followerCount)
+	utils.GetDB().Model(&models.Follow{}).Where("follower_id = ?", user.ID).Count(&followingCount)
+	resp["post_count"] = postCount
+	resp["comment_count"] = commentCount
+	resp["follower_count"] = followerCount
+	resp["following_count"] = followingCount
+	c.JSON(http.StatusOK, resp)
+}
+
+func userProfile(user models.User) gin.H {
+	avatarURL := user.AvatarURL
+	if avatarURL == "" {
+		avatarURL = utils.AvatarURL(&user.ID, nil)
+	}
+	return gin.H{
+		"id":           user.ID,
+		"username":     user.Username,
+		"display_name": user.DisplayName,
+		"bio":          user.Bio,
+		"avatar_url":   avatarURL,
+		"website":      user.Website,
+		"joined_at":    user.CreatedAt,
+	}
+}
+
+func ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		return
+	}
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if ok, _, err := utils.VerifyPassword(user.Password, req.CurrentPassword); !ok || err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if violations := utils.CheckPasswordPolicy(req.NewPassword, user.Username); len(violations) > 0 {
+		respondPasswordPolicy(c, violations)
+		return
+	}
+	hash, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	// Sign out every other session; the one changing the password stays valid.
+	// API keys are revoked too, since a leaked password may have been used to
+	// mint one.
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password", hash).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", user.ID, c.GetUint("sessionID")).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return utils.RevokeUserAPIKeys(tx, user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+func respondPasswordPolicy(c *gin.Context, violations []utils.PasswordViolation) {
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":   "Password does not meet requirements",
+		"details": gin.H{"password": violations},
+	})
+}
+
+// findUserParam loads the user whose numeric ID is in the :user path segment,
+// writing an error response and returning false if there is none.
+func findUserParam(c *gin.Context) (models.User, bool) {
+	var user models.User
+	id, err := strconv.Atoi(c.Param("user"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return user, false
+	}
+	if err := utils.GetDB().First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return user, false
+	}
+	return user, true
 }
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// visibleContent limits a posts or comments query, on table, to rows the
+// viewer may see: approved content by authors they have not muted and who
+// are not shadow-banned, plus their own content in any moderation state.
+func visibleContent(c *gin.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Scopes(
+			excludeUnapproved(c, table),
+			excludeMuted(c, table+".user_id"),
+			excludeShadowBanned(c, table+".user_id"),
+		)
+	}
+}
+
+// excludeShadowBanned hides rows whose author, in column, is shadow-banned,
+// unless the viewer is that author.
+func excludeShadowBanned(c *gin.Context, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		banned := column + " IS NULL OR " + column + " NOT IN (SELECT id FROM users WHERE shadow_banned_at IS NOT NULL)"
+		viewerID, ok := c.Get("userID")
+		if !ok {
+			return db.Where(banned)
+		}
+		return db.Where(banned+" OR "+column+" = ?", viewerID)
+	}
+}
+
+// excludeMuted hides rows whose author, in column, has been muted by the
+// authenticated viewer. Anonymous viewers see everything.
+func excludeMuted(c *gin.Context, column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		viewerID, ok := c.Get("userID")
+		if !ok {
+			return db
+		}
+		return db.Where(column+" IS NULL OR "+column+" NOT IN (SELECT muted_id FROM mutes WHERE muter_id = ?)", viewerID)
+	}
+}
+
+// excludeUnapproved hides content that is not approved unless the viewer
+// wrote it.
+func excludeUnapproved(c *gin.Context, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		viewerID, ok := c.Get("userID")
+		if !ok {
+			return db.Where(table+".moderation_state = ?", models.ModerationApproved)
+		}
+		return db.Where(table+".moderation_state = ? OR "+table+".user_id = ?", models.ModerationApproved, viewerID)
+	}
+}
+
+// canView reports whether the viewer may see a single item in the given
+// moderation state written by authorID. Like visibleContent, it hides
+// content by shadow-banned authors from everyone else.
+func canView(c *gin.Context, state string, authorID *uint) bool {
+	viewerID, ok := c.Get("userID")
+	if ok && authorID != nil && *authorID == viewerID.(uint) {
+		return true
+	}
+	if state != models.ModerationApproved {
+		return false
+	}
+	if authorID == nil {
+		return true
+	}
+	var banned int64
+	utils.GetDB().Model(&models.User{}).Where("id = ? AND shadow_banned_at IS NOT NULL", *authorID).Count(&banned)
+	return banned == 0
+}
@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+type WordFilterRequest struct {
+	Pattern string `json:"pattern" binding:"required,max=255"`
+	IsRegex bool   `json:"is_regex"`
+	Mode    string `json:"mode" binding:"required,oneof=reject mask queue"`
+}
+
+func ListWordFilters(c *gin.Context) {
+	var filters []models.WordFilter
+	if err := utils.GetDB().Order("id ASC").Find(&filters).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch word filters"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"word_filters": filters})
+}
+
+func CreateWordFilter(c *gin.Context) {
+	var req WordFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter := models.WordFilter{
+		Pattern:   req.Pattern,
+		IsRegex:   req.IsRegex,
+		Mode:      req.Mode,
+		CreatedBy: c.GetUint("userID"),
+	}
+	if _, err := utils.CompileWordFilter(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pattern: " + err.Error()})
+		return
+	}
+	err := utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&filter).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "word_filter.create", auditTargetWordFilter, filter.ID, nil, filter, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create word filter"})
+		return
+	}
+	utils.InvalidateWordFilters()
+	c.JSON(http.StatusCreated, filter)
+}
+
+func UpdateWordFilter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word filter ID"})
+		return
+	}
+	var filter models.WordFilter
+	if err := utils.GetDB().First(&filter, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Word filter not found"})
+		return
+	}
+	var req WordFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	before := filter
+	filter.Pattern = req.Pattern
+	filter.IsRegex = req.IsRegex
+	filter.Mode = req.Mode
+	if _, err := utils.CompileWordFilter(filter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pattern: " + err.Error()})
+		return
+	}
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&filter).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "word_filter.update", auditTargetWordFilter, filter.ID, before, filter, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update word filter"})
+		return
+	}
+	utils.InvalidateWordFilters()
+	c.JSON(http.StatusOK, filter)
+}
+
+func DeleteWordFilter(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word filter ID"})
+		return
+	}
+	var filter models.WordFilter
+	if err := utils.GetDB().First(&filter, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Word filter not found"})
+		return
+	}
+	err = utils.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&filter).Error; err != nil {
+			return err
+		}
+		return recordModeration(tx, c, "word_filter.delete", auditTargetWordFilter, filter.ID, filter, nil, "")
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete word filter"})
+		return
+	}
+	utils.InvalidateWordFilters()
+	c.JSON(http.StatusOK, gin.H{"message": "Word filter deleted"})
+}
+
+// filterWords applies the word filters to texts, masking in place. It
+// responds and returns false when a reject filter matched, and moves approved
+// content to pending when a queue filter matched.
+func filterWords(c *gin.Context, state *string, texts ...*string) bool {
+	result := utils.ApplyWordFilters(texts...)
+	if result.Reject {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Content contains banned words"})
+		return false
+	}
+	if result.Queue && *state == models.ModerationApproved {
+		*state = models.ModerationPending
+	}
+	return true
+}
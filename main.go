@@ -20,6 +20,10 @@ func main() {
 
 	// Initialize database connection
 	utils.InitDB()
+	utils.MigrateDB()
+
+	// Complete account deletions whose grace period has passed
+	utils.StartAccountDeletionWorker(config.AppConfig.AccountDeletionInterval)
 
 	// Initialize routes
 	r := routes.SetupRouter()
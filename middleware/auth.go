@@ -1,35 +1,231 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"post-comments-api/config"
+	"post-comments-api/models"
+	"post-comments-api/utils"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+const (
+	apiKeyLastUsedInterval  = time.Minute
+	sessionLastSeenInterval = time.Minute
+)
+
+// AuthMiddleware authenticates the request with a JWT, taken from the bearer
+// header or the browser session cookie. When scopes are given, personal API
+// keys carrying all of those scopes are accepted too; routes without scopes
+// are only reachable with a JWT.
+func AuthMiddleware(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		header := c.GetHeader("Authorization")
-		if header == "" || !strings.HasPrefix(header, "Bearer ") {
+		if key := apiKeyFromRequest(c); key != "" {
+			authenticateAPIKey(c, key, scopes)
+			return
+		}
+		tokenStr, fromCookie := sessionToken(c)
+		if tokenStr == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Missing or invalid Authorization header"})
 			return
 		}
-		tokenStr := strings.TrimPrefix(header, "Bearer ")
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return []byte(config.AppConfig.JWTSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		claims, userID, sid, reason := parseAccessToken(tokenStr)
+		if reason != "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+			return
+		}
+		if fromCookie && !validCSRF(c, sid) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing or invalid CSRF token"})
 			return
 		}
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || claims["user_id"] == nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if !checkSession(c, userID, sid) {
+			return
+		}
+		c.Set("sessionID", sid)
+		actorID, impersonating := claims["act"].(float64)
+		if impersonating {
+			if fromCookie || !checkImpersonator(c, uint(actorID)) {
+				return
+			}
+			c.Set("impersonatorID", uint(actorID))
+		}
+		if !checkUserActive(c, userID) {
+			return
+		}
+		c.Set("userID", userID)
+		c.Set("authMethod", "jwt")
+		if impersonating {
+			auditImpersonatedRequest(c, userID, uint(actorID))
 			return
 		}
-		c.Set("userID", uint(claims["user_id"].(float64)))
 		c.Next()
 	}
 }
+
+// checkUserActive rejects requests from users that no longer exist or are
+// currently suspended.
+func checkUserActive(c *gin.Context, userID uint) bool {
+	var user models.User
+	if err := utils.GetDB().First(&user, userID).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return false
+	}
+	if utils.IsSuspended(&user) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error":           "Account suspended",
+			"reason":          user.SuspensionReason,
+			"suspended_until": user.SuspendedUntil,
+		})
+		return false
+	}
+	return true
+}
+
+// parseAccessToken verifies an access token and returns its claims and the
+// user and session it was issued for. On failure reason holds the message to
+// send back.
+func parseAccessToken(tokenStr string) (claims jwt.MapClaims, userID, sessionID uint, reason string) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, 0, 0, "Invalid token"
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["user_id"] == nil || claims["purpose"] != nil {
+		return nil, 0, 0, "Invalid token claims"
+	}
+	// Every access token is bound to a session so it can be signed out;
+	// tokens from before sessions existed must log in again.
+	sid, hasSession := claims["sid"].(float64)
+	if !hasSession {
+		return nil, 0, 0, "Invalid token claims"
+	}
+	return claims, uint(claims["user_id"].(float64)), uint(sid), ""
+}
+
+// activeSession loads the login session of userID, or returns the reason it
+// can no longer be used.
+func activeSession(userID, sessionID uint) (*models.Session, string) {
+	var session models.Session
+	if err := utils.GetDB().Where("user_id = ?", userID).First(&session, sessionID).Error; err != nil {
+		return nil, "Session not found"
+	}
+	if session.RevokedAt != nil || session.ExpiresAt.Before(time.Now()) {
+		return nil, "Session has been signed out"
+	}
+	return &session, ""
+}
+
+// checkSession rejects tokens whose login session was revoked or has expired,
+// and refreshes the session's last-seen time.
+func checkSession(c *gin.Context, userID, sessionID uint) bool {
+	session, reason := activeSession(userID, sessionID)
+	if reason != "" {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": reason})
+		return false
+	}
+	if now := time.Now(); now.Sub(session.LastSeenAt) > sessionLastSeenInterval {
+		utils.GetDB().Model(session).Updates(map[string]interface{}{"last_seen_at": now, "ip": c.ClientIP()})
+	}
+	return true
+}
+
+// sessionToken returns the JWT from the Authorization header, falling back to
+// the session cookie. fromCookie is set when the cookie was used.
+func sessionToken(c *gin.Context) (token string, fromCookie bool) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if !strings.HasPrefix(header, "Bearer ") {
+			return "", false
+		}
+		return strings.TrimPrefix(header, "Bearer "), false
+	}
+	if cookie, err := c.Cookie(utils.SessionCookieName); err == nil && cookie != "" {
+		return cookie, true
+	}
+	return "", false
+}
+
+// validCSRF applies double-submit validation to state-changing requests made
+// with the session cookie: the header must match both the CSRF cookie and the
+// token derived from the session.
+func validCSRF(c *gin.Context, sessionID uint) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	header := c.GetHeader(utils.CSRFHeaderName)
+	cookie, err := c.Cookie(utils.CSRFCookieName)
+	if header == "" || err != nil {
+		return false
+	}
+	expected := utils.CSRFToken(sessionID)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(header), []byte(expected)) == 1
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "ApiKey ") {
+		return strings.TrimPrefix(header, "ApiKey ")
+	}
+	return ""
+}
+
+func authenticateAPIKey(c *gin.Context, key string, scopes []string) {
+	if len(scopes) == 0 {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API keys are not accepted for this endpoint"})
+		return
+	}
+	prefix, ok := utils.ParseAPIKeyPrefix(key)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	}
+	var apiKey models.APIKey
+	if err := utils.GetDB().Where("prefix = ?", prefix).First(&apiKey).Error; err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(utils.HashAPIKey(key))) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return
+	}
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(now) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key has expired"})
+		return
+	}
+	granted := strings.Split(apiKey.Scopes, ",")
+	for _, scope := range scopes {
+		if !containsString(granted, scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing scope " + scope})
+			return
+		}
+	}
+	if !checkUserActive(c, apiKey.UserID) {
+		return
+	}
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyLastUsedInterval {
+		utils.GetDB().Model(&apiKey).Update("last_used_at", now)
+	}
+	c.Set("userID", apiKey.UserID)
+	c.Set("authMethod", "api_key")
+	c.Set("apiKeyID", apiKey.ID)
+	c.Next()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
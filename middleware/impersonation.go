@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// BlockImpersonation rejects the request when it is made with an impersonation
+// token. Use it on account-sensitive routes such as password changes.
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("impersonatorID"); ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// checkImpersonator makes sure the admin behind an impersonation token still
+// holds the admin role and is not suspended.
+func checkImpersonator(c *gin.Context, actorID uint) bool {
+	var actor models.User
+	if err := utils.GetDB().First(&actor, actorID).Error; err != nil ||
+		actor.Role != models.RoleAdmin || utils.IsSuspended(&actor) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Impersonation is no longer permitted"})
+		return false
+	}
+	return true
+}
+
+// auditImpersonatedRequest writes the impersonation audit entry before the
+// handler runs, so no impersonated request goes unrecorded, then fills in the
+// response status once it finishes. If the entry cannot be written the request
+// is refused.
+func auditImpersonatedRequest(c *gin.Context, userID, actorID uint) {
+	entry := models.ImpersonationAudit{
+		ImpersonatorID: actorID,
+		UserID:         userID,
+		Method:         c.Request.Method,
+		Path:           utils.TruncateString(c.Request.URL.RequestURI(), 500),
+		IP:             c.ClientIP(),
+		UserAgent:      utils.TruncateString(c.Request.UserAgent(), 255),
+	}
+	if err := utils.GetDB().Create(&entry).Error; err != nil {
+		log.Error().Err(err).Uint("user_id", userID).Uint("impersonator_id", actorID).Msg("failed to record impersonated request")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to record impersonated request"})
+		return
+	}
+	c.Next()
+	if err := utils.GetDB().Model(&entry).Update("status", c.Writer.Status()).Error; err != nil {
+		log.Error().Err(err).Uint("audit_id", entry.ID).Msg("failed to record impersonated request status")
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/utils"
+)
+
+// IPBanMiddleware rejects requests from banned addresses. It relies on
+// c.ClientIP, so the engine's trusted proxies must be configured.
+func IPBanMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ban := utils.FindIPBan(c.ClientIP()); ban != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":      "Your IP address is banned",
+				"expires_at": ban.ExpiresAt,
+			})
+			return
+		}
+		c.Next()
+	}
+}
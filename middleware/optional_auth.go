@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"post-comments-api/utils"
+)
+
+// OptionalAuthMiddleware lets anonymous requests through untouched but
+// authenticates requests that carry credentials, so public endpoints can
+// tailor results to the viewer. Bearer tokens and API keys that are sent must
+// be valid. A session cookie that is stale or invalid is cleared and the
+// request continues anonymously, so a signed-out browser can still read
+// public pages.
+func OptionalAuthMiddleware(scopes ...string) gin.HandlerFunc {
+	auth := AuthMiddleware(scopes...)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" || c.GetHeader("X-API-Key") != "" {
+			auth(c)
+			return
+		}
+		cookie, err := c.Cookie(utils.SessionCookieName)
+		if err != nil || cookie == "" {
+			c.Next()
+			return
+		}
+		if !validSessionCookie(cookie) {
+			utils.ClearSessionCookies(c.Writer)
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// validSessionCookie reports whether cookie holds an access token for a live
+// session.
+func validSessionCookie(cookie string) bool {
+	_, userID, sessionID, reason := parseAccessToken(cookie)
+	if reason != "" {
+		return false
+	}
+	_, reason = activeSession(userID, sessionID)
+	return reason == ""
+}
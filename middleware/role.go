@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"post-comments-api/models"
+	"post-comments-api/utils"
+)
+
+// RequireRole must run after AuthMiddleware. It loads the current user and
+// rejects the request unless their role is one of roles.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := utils.GetDB().First(&user, c.GetUint("userID")).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				c.Set("userRole", user.Role)
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+)
+
+type APIKey struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	UserID     uint           `json:"user_id" gorm:"not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string         `json:"prefix" gorm:"type:varchar(16);not null;uniqueIndex"`
+	KeyHash    string         `json:"-" gorm:"type:char(64);not null"`
+	Scopes     string         `json:"-" gorm:"type:varchar(255);not null"`
+	ExpiresAt  *time.Time     `json:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+	DeletedAt  gorm.DeletedAt `json:"-" gorm:"index"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Block stops BlockedID from commenting on BlockerID's posts.
+type Block struct {
+	BlockerID uint      `json:"blocker_id" gorm:"primaryKey"`
+	BlockedID uint      `json:"blocked_id" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Mute hides MutedID's posts and comments from MuterID.
+type Mute struct {
+	MuterID   uint      `json:"muter_id" gorm:"primaryKey"`
+	MutedID   uint      `json:"muted_id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+}
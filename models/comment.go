@@ -6,12 +6,22 @@ import (
 )
 
 type Comment struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	PostID    uint           `json:"post_id" gorm:"not null;index"`
-	UserID    *uint          `json:"user_id" gorm:"index"`
-	Author    *string        `json:"author,omitempty" gorm:"type:varchar(100);"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey"`
+	PostID          uint           `json:"post_id" gorm:"not null;index"`
+	UserID          *uint          `json:"user_id" gorm:"index"`
+	Author          *string        `json:"author,omitempty" gorm:"type:varchar(100);"`
+	Content         string         `json:"content" gorm:"type:text;not null"`
+	ModerationState string         `json:"moderation_state" gorm:"type:varchar(20);not null;default:approved;index"`
+	ModeratedBy     *uint          `json:"moderated_by,omitempty"`
+	ModeratedAt     *time.Time     `json:"moderated_at,omitempty"`
+	HiddenByReports bool           `json:"-" gorm:"not null;default:false"`
+	SpamScore       float64        `json:"spam_score"`
+	SpamLabel       string         `json:"-" gorm:"type:varchar(10)"`
+	ContentHash     string         `json:"-" gorm:"type:varchar(64);index"`
+	SimHash         int64          `json:"-"`
+	IP              string         `json:"-" gorm:"type:varchar(45)"`
+	PinnedAt        *time.Time     `json:"pinned_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ImpersonationAudit records one request made by an admin while impersonating
+// another user. It is written before the request is handled; Status stays 0
+// if the handler never completes.
+type ImpersonationAudit struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ImpersonatorID uint      `json:"impersonator_id" gorm:"not null;index"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	Method         string    `json:"method" gorm:"type:varchar(10);not null"`
+	Path           string    `json:"path" gorm:"type:varchar(500);not null"`
+	Status         int       `json:"status"`
+	IP             string    `json:"ip" gorm:"type:varchar(45)"`
+	UserAgent      string    `json:"user_agent" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
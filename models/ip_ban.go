@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// IPBan blocks every request from an address or CIDR range until ExpiresAt,
+// or forever when ExpiresAt is nil. Single addresses are stored as /32 or
+// /128 ranges.
+type IPBan struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	CIDR      string     `json:"cidr" gorm:"column:cidr;type:varchar(50);uniqueIndex;not null"`
+	Reason    string     `json:"reason" gorm:"type:varchar(500)"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	CreatedBy uint       `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
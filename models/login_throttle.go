@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginThrottle tracks failed login attempts for a username or client IP.
+type LoginThrottle struct {
+	Key           string     `json:"key" gorm:"primaryKey;type:varchar(150)"`
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,8 @@
+package models
+
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+	ModerationSpam     = "spam"
+)
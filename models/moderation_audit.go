@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogAppendOnly is returned when code tries to change or remove a
+// moderation audit entry.
+var ErrAuditLogAppendOnly = errors.New("moderation audit log is append-only")
+
+// ModerationAudit records one moderator or admin action. Before and After
+// hold JSON snapshots of the target. Entries are never updated or deleted:
+// the hooks below catch mistakes in application code, and a trigger created
+// by MigrateDB enforces it in the database.
+type ModerationAudit struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ActorID        uint      `json:"actor_id" gorm:"not null;index"`
+	ImpersonatorID *uint     `json:"impersonator_id,omitempty"`
+	Action         string    `json:"action" gorm:"type:varchar(50);not null;index"`
+	TargetType     string    `json:"target_type" gorm:"type:varchar(20);not null;index:idx_moderation_audits_target,priority:1"`
+	TargetID       uint      `json:"target_id" gorm:"index:idx_moderation_audits_target,priority:2"`
+	Before         string    `json:"-" gorm:"type:text"`
+	After          string    `json:"-" gorm:"type:text"`
+	Reason         string    `json:"reason" gorm:"type:varchar(500)"`
+	Method         string    `json:"method" gorm:"type:varchar(10)"`
+	Path           string    `json:"path" gorm:"type:varchar(500)"`
+	IP             string    `json:"ip" gorm:"type:varchar(45)"`
+	UserAgent      string    `json:"user_agent" gorm:"type:varchar(255)"`
+	CreatedAt      time.Time `json:"created_at" gorm:"index"`
+}
+
+func (ModerationAudit) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
+
+func (ModerationAudit) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogAppendOnly
+}
@@ -6,13 +6,29 @@ import (
 )
 
 type Post struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	UserID    *uint          `json:"user_id" gorm:"index"`
-	Author    *string        `json:"author,omitempty" gorm:"type:varchar(100);"`
-	Title     string         `json:"title" gorm:"type:varchar(255);not null"`
-	Content   string         `json:"content" gorm:"type:text;not null"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	Comments  []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID              uint           `json:"id" gorm:"primaryKey;index:idx_posts_user_created_id,priority:3"`
+	UserID          *uint          `json:"user_id" gorm:"index;index:idx_posts_user_created_id,priority:1"`
+	Author          *string        `json:"author,omitempty" gorm:"type:varchar(100);"`
+	Title           string         `json:"title" gorm:"type:varchar(255);not null"`
+	Content         string         `json:"content" gorm:"type:text;not null"`
+	ModerationState string         `json:"moderation_state" gorm:"type:varchar(20);not null;default:approved;index"`
+	ModeratedBy     *uint          `json:"moderated_by,omitempty"`
+	ModeratedAt     *time.Time     `json:"moderated_at,omitempty"`
+	HiddenByReports bool           `json:"-" gorm:"not null;default:false"`
+	ContentHash     string         `json:"-" gorm:"type:varchar(64);index"`
+	SimHash         int64          `json:"-"`
+	IP              string         `json:"-" gorm:"type:varchar(45)"`
+	CommentsLocked  bool           `json:"comments_locked" gorm:"not null;default:false"`
+	LockedBy        *uint          `json:"-"`
+	CommentsCloseAt *time.Time     `json:"comments_close_at,omitempty"`
+	CloseAtSetBy    *uint          `json:"-"`
+	SlowModeSeconds int            `json:"slow_mode_seconds" gorm:"not null;default:0"`
+	SlowModeSetBy   *uint          `json:"-"`
+	PinnedAt        *time.Time     `json:"pinned_at,omitempty" gorm:"index"`
+	PinnedUntil     *time.Time     `json:"pinned_until,omitempty"`
+	PinnedBy        *uint          `json:"pinned_by,omitempty"`
+	CreatedAt       time.Time      `json:"created_at" gorm:"index:idx_posts_user_created_id,priority:2"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	Comments        []Comment      `json:"comments,omitempty" gorm:"foreignKey:PostID"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
 }
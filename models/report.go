@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	ReportTargetPost    = "post"
+	ReportTargetComment = "comment"
+
+	ReportOpen      = "open"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Report is a reader's complaint about a post or comment. Each reporter can
+// report a given item once.
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	TargetType string     `json:"target_type" gorm:"type:varchar(20);not null;uniqueIndex:idx_reports_target_reporter,priority:1;index:idx_reports_target,priority:1"`
+	TargetID   uint       `json:"target_id" gorm:"not null;uniqueIndex:idx_reports_target_reporter,priority:2;index:idx_reports_target,priority:2"`
+	ReporterID uint       `json:"reporter_id" gorm:"not null;uniqueIndex:idx_reports_target_reporter,priority:3"`
+	Reason     string     `json:"reason" gorm:"type:varchar(30);not null"`
+	Details    string     `json:"details" gorm:"type:text"`
+	Status     string     `json:"status" gorm:"type:varchar(20);not null;default:open;index"`
+	ResolvedBy *uint      `json:"resolved_by,omitempty"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+	Resolution string     `json:"resolution,omitempty" gorm:"type:varchar(20)"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
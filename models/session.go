@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+type Session struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IP         string     `json:"ip" gorm:"type:varchar(45)"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// ImpersonatorID is the admin acting as the user when the session
+	// backs an impersonation token.
+	ImpersonatorID *uint `json:"impersonator_id,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// SpamToken holds how many spam and ham comments a token has appeared in,
+// as learned from moderator decisions.
+type SpamToken struct {
+	Token     string `gorm:"primaryKey;type:varchar(64)"`
+	SpamCount int    `gorm:"not null;default:0"`
+	HamCount  int    `gorm:"not null;default:0"`
+}
+
+// SpamCorpus counts the comments the spam classifier was trained on. There
+// is a single row with ID 1.
+type SpamCorpus struct {
+	ID       uint `gorm:"primaryKey"`
+	SpamDocs int  `gorm:"not null;default:0"`
+	HamDocs  int  `gorm:"not null;default:0"`
+}
+
+const (
+	SpamLabelSpam = "spam"
+	SpamLabelHam  = "ham"
+)
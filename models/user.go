@@ -1,11 +1,53 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Trust levels grow with account age and approved content. Staff
+// (moderators and admins) always have TrustLevelStaff.
+const (
+	TrustLevelNew    = 0
+	TrustLevelBasic  = 1
+	TrustLevelMember = 2
+	TrustLevelStaff  = 3
+)
+
+const (
+	DeletionModeAnonymize = "anonymize"
+	DeletionModeDelete    = "delete"
+)
 
 type User struct {
 	gorm.Model
-	ID       uint   `json:"id" gorm:"primaryKey"`
-	Username string `json:"username" gorm:"unique;not null"`
-	Password string `json:"-" gorm:"not null"`
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	Username     string `json:"username" gorm:"unique;not null"`
+	Password     string `json:"-" gorm:"not null"`
+	Role         string `json:"role" gorm:"type:varchar(20);not null;default:user"`
+	DisplayName  string `json:"display_name" gorm:"type:varchar(100)"`
+	Bio          string `json:"bio" gorm:"type:varchar(500)"`
+	AvatarURL    string `json:"avatar_url" gorm:"type:varchar(500)"`
+	Website      string `json:"website" gorm:"type:varchar(255)"`
+	TOTPSecret   string `json:"-" gorm:"column:totp_secret;type:varchar(64)"`
+	TOTPEnabled  bool   `json:"totp_enabled" gorm:"column:totp_enabled;not null;default:false"`
+	TOTPLastStep int64  `json:"-" gorm:"column:totp_last_step;not null;default:0"`
+
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	SuspensionReason string     `json:"suspension_reason,omitempty" gorm:"type:varchar(500)"`
+	SuspendedBy      *uint      `json:"suspended_by,omitempty"`
+
+	ShadowBannedAt *time.Time `json:"-" gorm:"index"`
+	ShadowBannedBy *uint      `json:"-"`
+
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" gorm:"index"`
+	DeletionMode        string     `json:"deletion_mode,omitempty" gorm:"type:varchar(20)"`
 }
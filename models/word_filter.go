@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	WordFilterReject = "reject"
+	WordFilterMask   = "mask"
+	WordFilterQueue  = "queue"
+)
+
+// WordFilter is a banned word, phrase or regular expression checked against
+// post and comment text. Mode decides what happens on a match.
+type WordFilter struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Pattern   string    `json:"pattern" gorm:"type:varchar(255);not null"`
+	IsRegex   bool      `json:"is_regex" gorm:"not null;default:false"`
+	Mode      string    `json:"mode" gorm:"type:varchar(10);not null"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -2,22 +2,110 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"post-comments-api/config"
 	"post-comments-api/controllers"
 	"post-comments-api/middleware"
+	"post-comments-api/models"
 )
 
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	// Without trusted proxies c.ClientIP is the connecting address, so
+	// forwarded headers cannot be spoofed to dodge IP bans.
+	if err := r.SetTrustedProxies(config.AppConfig.TrustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("invalid TRUSTED_PROXIES")
+	}
 
+	// The logger goes first so requests refused by an IP ban are logged too.
 	r.Use(middleware.LoggerMiddleware())
+	r.Use(middleware.IPBanMiddleware())
 
 	api := r.Group("/api")
 	{
 		auth := api.Group("/auth")
 		auth.POST("/register", controllers.Register)
 		auth.POST("/login", controllers.Login)
+		auth.POST("/login/mfa", controllers.LoginMFA)
+		auth.POST("/logout", middleware.AuthMiddleware(), controllers.Logout)
 
 		api.GET("/users/me", middleware.AuthMiddleware(), controllers.GetCurrentUser)
+		api.PATCH("/users/me", middleware.AuthMiddleware(), controllers.UpdateProfile)
+		api.PUT("/users/me/password", middleware.AuthMiddleware(), middleware.BlockImpersonation(), controllers.ChangePassword)
+		api.GET("/users/me/export", middleware.AuthMiddleware(), middleware.BlockImpersonation(), controllers.ExportAccount)
+		api.DELETE("/users/me", middleware.AuthMiddleware(), middleware.BlockImpersonation(), controllers.DeleteAccount)
+		api.POST("/users/me/deletion/cancel", middleware.AuthMiddleware(), middleware.BlockImpersonation(), controllers.CancelAccountDeletion)
+
+		// Gin requires one wildcard name per path segment, so :user is a
+		// username for the profile and a numeric ID for the listings.
+		api.GET("/users/:user", controllers.GetUserProfile)
+		api.GET("/users/:user/posts", middleware.OptionalAuthMiddleware(models.ScopePostsRead), controllers.GetUserPosts)
+		api.GET("/users/:user/comments", middleware.OptionalAuthMiddleware(models.ScopeCommentsRead), controllers.GetUserComments)
+		api.POST("/users/:user/follow", middleware.AuthMiddleware(), controllers.FollowUser)
+		api.DELETE("/users/:user/follow", middleware.AuthMiddleware(), controllers.UnfollowUser)
+		api.POST("/users/:user/block", middleware.AuthMiddleware(), controllers.BlockUser)
+		api.DELETE("/users/:user/block", middleware.AuthMiddleware(), controllers.UnblockUser)
+		api.POST("/users/:user/mute", middleware.AuthMiddleware(), controllers.MuteUser)
+		api.DELETE("/users/:user/mute", middleware.AuthMiddleware(), controllers.UnmuteUser)
+
+		api.GET("/users/me/blocks", middleware.AuthMiddleware(), controllers.ListBlocks)
+		api.GET("/users/me/mutes", middleware.AuthMiddleware(), controllers.ListMutes)
+
+		api.GET("/feed", middleware.AuthMiddleware(models.ScopePostsRead), controllers.GetFeed)
+
+		// Login sessions
+		api.GET("/users/me/sessions", middleware.AuthMiddleware(), controllers.ListSessions)
+		api.DELETE("/users/me/sessions/:id", middleware.AuthMiddleware(), middleware.BlockImpersonation(), controllers.RevokeSession)
+
+		// Two-factor authentication
+		mfa := api.Group("/users/me/mfa", middleware.AuthMiddleware(), middleware.BlockImpersonation())
+		mfa.POST("/totp", controllers.EnrollTOTP)
+		mfa.POST("/totp/confirm", controllers.ConfirmTOTP)
+		mfa.DELETE("/totp", controllers.DisableTOTP)
+		mfa.POST("/recovery-codes", controllers.RegenerateRecoveryCodes)
+
+		// Personal API keys
+		keys := api.Group("/users/me/api-keys", middleware.AuthMiddleware(), middleware.BlockImpersonation())
+		keys.GET("", controllers.ListAPIKeys)
+		keys.POST("", controllers.CreateAPIKey)
+		keys.DELETE("/:id", controllers.DeleteAPIKey)
+
+		// Admin
+		admin := api.Group("/admin", middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+		admin.GET("/users", controllers.AdminListUsers)
+		admin.GET("/users/:id", controllers.AdminGetUser)
+		admin.POST("/users/:id/suspend", controllers.SuspendUser)
+		admin.DELETE("/users/:id/suspend", controllers.UnsuspendUser)
+		admin.POST("/users/:id/shadow-ban", controllers.ShadowBanUser)
+		admin.DELETE("/users/:id/shadow-ban", controllers.UnshadowBanUser)
+		admin.POST("/users/:id/reset-password", controllers.AdminResetPassword)
+		admin.PUT("/users/:id/role", controllers.ChangeUserRole)
+		admin.POST("/users/:id/logout", controllers.ForceLogoutUser)
+		admin.POST("/users/:id/unlock", controllers.UnlockUser)
+		admin.POST("/users/:id/impersonate", controllers.ImpersonateUser)
+		admin.GET("/impersonations", controllers.ListImpersonationAudit)
+		admin.GET("/audit", controllers.ListModerationAudit)
+		admin.GET("/ip-bans", controllers.ListIPBans)
+		admin.POST("/ip-bans", controllers.CreateIPBan)
+		admin.DELETE("/ip-bans/:id", controllers.DeleteIPBan)
+
+		// Moderation
+		moderation := api.Group("/moderation", middleware.AuthMiddleware(), middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+		moderation.GET("/queue", controllers.GetModerationQueue)
+		moderation.POST("/queue/approve", controllers.ApproveContent)
+		moderation.POST("/queue/reject", controllers.RejectContent)
+		moderation.GET("/reports", controllers.ListReports)
+		moderation.POST("/reports/resolve", controllers.ResolveReports)
+		moderation.DELETE("/posts/:id", controllers.ModeratorDeletePost)
+		moderation.DELETE("/comments/:id", controllers.ModeratorDeleteComment)
+		moderation.POST("/posts/:id/pin", controllers.PinPost)
+		moderation.DELETE("/posts/:id/pin", controllers.UnpinPost)
+		moderation.GET("/word-filters", controllers.ListWordFilters)
+		moderation.POST("/word-filters", controllers.CreateWordFilter)
+		moderation.PUT("/word-filters/:id", controllers.UpdateWordFilter)
+		moderation.DELETE("/word-filters/:id", controllers.DeleteWordFilter)
+
+		api.GET("/avatars/:file", controllers.GetAvatar)
 
 		// Public posts/comments
 		public := api.Group("/public")
@@ -25,18 +113,23 @@ func SetupRouter() *gin.Engine {
 		public.POST("/comments", controllers.CreateCommentPublic)
 
 		// Protected posts
-		api.GET("/posts", controllers.GetPosts)
-		api.POST("/posts", middleware.AuthMiddleware(), controllers.CreatePost)
-		api.GET("/posts/:id", controllers.GetPost)
-		api.PUT("/posts/:id", middleware.AuthMiddleware(), controllers.UpdatePost)
-		api.DELETE("/posts/:id", middleware.AuthMiddleware(), controllers.DeletePost)
+		api.GET("/posts", middleware.OptionalAuthMiddleware(models.ScopePostsRead), controllers.GetPosts)
+		api.POST("/posts", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.CreatePost)
+		api.GET("/posts/:id", middleware.OptionalAuthMiddleware(models.ScopePostsRead), controllers.GetPost)
+		api.PUT("/posts/:id", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.UpdatePost)
+		api.DELETE("/posts/:id", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.DeletePost)
+		api.POST("/posts/:id/reports", middleware.AuthMiddleware(), controllers.ReportPost)
+		api.PUT("/posts/:id/comment-settings", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.UpdateCommentSettings)
 
 		// Comments
-		api.GET("/posts/:id/comments", controllers.GetComments)
-		api.POST("/posts/:id/comments", middleware.AuthMiddleware(), controllers.CreateComment)
-		api.POST("/comments", middleware.AuthMiddleware(), controllers.CreateComment)
-		api.PUT("/comments/:id", middleware.AuthMiddleware(), controllers.UpdateComment)
-		api.DELETE("/comments/:id", middleware.AuthMiddleware(), controllers.DeleteComment)
+		api.GET("/posts/:id/comments", middleware.OptionalAuthMiddleware(models.ScopeCommentsRead), controllers.GetComments)
+		api.POST("/posts/:id/comments", middleware.AuthMiddleware(models.ScopeCommentsWrite), controllers.CreateComment)
+		api.POST("/comments", middleware.AuthMiddleware(models.ScopeCommentsWrite), controllers.CreateComment)
+		api.PUT("/comments/:id", middleware.AuthMiddleware(models.ScopeCommentsWrite), controllers.UpdateComment)
+		api.DELETE("/comments/:id", middleware.AuthMiddleware(models.ScopeCommentsWrite), controllers.DeleteComment)
+		api.POST("/comments/:id/reports", middleware.AuthMiddleware(), controllers.ReportComment)
+		api.POST("/comments/:id/pin", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.PinComment)
+		api.DELETE("/comments/:id/pin", middleware.AuthMiddleware(models.ScopePostsWrite), controllers.UnpinComment)
 	}
 
 	return r
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"post-comments-api/models"
+)
+
+// DeletedAuthorName replaces the author of anonymized posts and comments.
+const DeletedAuthorName = "[deleted]"
+
+// StartAccountDeletionWorker periodically purges accounts whose deletion
+// grace period has passed. It runs until the process exits.
+func StartAccountDeletionWorker(interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			PurgeDueAccounts()
+			<-ticker.C
+		}
+	}()
+}
+
+// PurgeDueAccounts completes every scheduled deletion that is now due.
+func PurgeDueAccounts() {
+	var users []models.User
+	if err := GetDB().Where("deletion_scheduled_at <= ?", time.Now()).Find(&users).Error; err != nil {
+		log.Error().Err(err).Msg("failed to load accounts due for deletion")
+		return
+	}
+	for _, user := range users {
+		if err := PurgeAccount(&user); err != nil {
+			log.Error().Err(err).Uint("user_id", user.ID).Msg("failed to delete account")
+			continue
+		}
+		log.Info().Uint("user_id", user.ID).Str("mode", user.DeletionMode).Msg("account deleted")
+	}
+}
+
+// PurgeAccount permanently removes user. Authored posts and comments are
+// either anonymized or deleted according to user.DeletionMode.
+func PurgeAccount(user *models.User) error {
+	return GetDB().Transaction(func(tx *gorm.DB) error {
+		if user.DeletionMode == models.DeletionModeDelete {
+			postIDs := tx.Unscoped().Model(&models.Post{}).Select("id").Where("user_id = ?", user.ID)
+			if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(&models.Post{}).Error; err != nil {
+				return err
+			}
+		} else {
+			anonymized := map[string]interface{}{"user_id": nil, "author": DeletedAuthorName, "ip": ""}
+			if err := tx.Unscoped().Model(&models.Comment{}).Where("user_id = ?", user.ID).Updates(anonymized).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Model(&models.Post{}).Where("user_id = ?", user.ID).Updates(anonymized).Error; err != nil {
+				return err
+			}
+		}
+		for _, owned := range []interface{}{&models.RecoveryCode{}, &models.APIKey{}, &models.Session{}} {
+			if err := tx.Unscoped().Where("user_id = ?", user.ID).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("follower_id = ? OR followee_id = ?", user.ID, user.ID).Delete(&models.Follow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("blocker_id = ? OR blocked_id = ?", user.ID, user.ID).Delete(&models.Block{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("muter_id = ? OR muted_id = ?", user.ID, user.ID).Delete(&models.Mute{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("reporter_id = ?", user.ID).Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("key = ?", UsernameThrottleKey(user.Username)).Delete(&models.LoginThrottle{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(user).Error
+	})
+}
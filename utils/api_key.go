@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"gorm.io/gorm"
+	"post-comments-api/models"
+)
+
+const apiKeyPrefix = "pca"
+
+// GenerateAPIKey returns a new key of the form pca_<prefix>_<secret> along
+// with its prefix. Only the prefix and the hash of the full key are stored.
+func GenerateAPIKey() (key string, prefix string, err error) {
+	p := make([]byte, 4)
+	s := make([]byte, 24)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	if _, err := rand.Read(s); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(p)
+	return apiKeyPrefix + "_" + prefix + "_" + hex.EncodeToString(s), prefix, nil
+}
+
+// ParseAPIKeyPrefix extracts the lookup prefix from a presented key.
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevokeUserAPIKeys deletes every API key belonging to userID.
+func RevokeUserAPIKeys(tx *gorm.DB, userID uint) error {
+	return tx.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error
+}
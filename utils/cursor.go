@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor builds an opaque keyset pagination cursor pointing just past
+// the row with the given creation time and ID.
+func EncodeCursor(createdAt time.Time, id uint) string {
+	raw := fmt.Sprintf("%d_%d", createdAt.UnixNano(), id)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(cursor string) (time.Time, uint, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	var nanos int64
+	var id uint
+	if _, err := fmt.Sscanf(string(raw), "%d_%d", &nanos, &id); err != nil {
+		return time.Time{}, 0, errInvalidCursor
+	}
+	return time.Unix(0, nanos), id, nil
+}
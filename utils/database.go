@@ -3,11 +3,13 @@ package utils
 import (
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"post-comments-api/config"
+	"post-comments-api/models"
 )
 
 var (
@@ -31,9 +33,73 @@ func InitDB() {
 	db = database
 }
 
+// MigrateDB creates or updates the tables for all models.
+func MigrateDB() {
+	err := GetDB().AutoMigrate(
+		&models.User{},
+		&models.Post{},
+		&models.Comment{},
+		&models.RecoveryCode{},
+		&models.LoginThrottle{},
+		&models.APIKey{},
+		&models.Session{},
+		&models.ImpersonationAudit{},
+		&models.Follow{},
+		&models.Block{},
+		&models.Mute{},
+		&models.Report{},
+		&models.SpamToken{},
+		&models.SpamCorpus{},
+		&models.WordFilter{},
+		&models.ModerationAudit{},
+		&models.IPBan{},
+	)
+	if err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	for _, stmt := range moderationAuditAppendOnly {
+		if err := GetDB().Exec(stmt).Error; err != nil {
+			log.Fatalf("failed to protect moderation audit log: %v", err)
+		}
+	}
+}
+
+// moderationAuditAppendOnly makes Postgres refuse updates, deletes and
+// truncation of moderation_audits, whatever path the statement takes.
+var moderationAuditAppendOnly = []string{
+	`CREATE OR REPLACE FUNCTION moderation_audits_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'moderation audit log is append-only';
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS moderation_audits_append_only ON moderation_audits`,
+	`CREATE TRIGGER moderation_audits_append_only BEFORE UPDATE OR DELETE ON moderation_audits
+FOR EACH ROW EXECUTE PROCEDURE moderation_audits_append_only()`,
+	`DROP TRIGGER IF EXISTS moderation_audits_no_truncate ON moderation_audits`,
+	`CREATE TRIGGER moderation_audits_no_truncate BEFORE TRUNCATE ON moderation_audits
+FOR EACH STATEMENT EXECUTE PROCEDURE moderation_audits_append_only()`,
+}
+
+// IsDuplicateKey reports whether err is a unique constraint violation.
+func IsDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	detail := err.Error()
+	return strings.Contains(detail, "duplicate key") || strings.Contains(detail, "UNIQUE constraint failed")
+}
+
 func GetDB() *gorm.DB {
 	if db == nil {
 		InitDB()
 	}
 	return db
 }
+
+// SetDB replaces the database connection. Tests use it to run against an
+// in-memory database.
+func SetDB(database *gorm.DB) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	db = database
+}
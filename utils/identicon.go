@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+)
+
+const identiconGrid = 5
+
+// AvatarHash derives the stable identicon hash for a registered user or, when
+// userID is nil, for a guest author name.
+func AvatarHash(userID *uint, author *string) string {
+	seed := "guest:anonymous"
+	if userID != nil {
+		seed = "user:" + strconv.FormatUint(uint64(*userID), 10)
+	} else if author != nil && strings.TrimSpace(*author) != "" {
+		seed = "guest:" + strings.ToLower(strings.TrimSpace(*author))
+	}
+	sum := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(sum[:16])
+}
+
+func AvatarURL(userID *uint, author *string) string {
+	return "/api/avatars/" + AvatarHash(userID, author) + ".png"
+}
+
+// IsAvatarHash reports whether s looks like a hash produced by AvatarHash.
+func IsAvatarHash(s string) bool {
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// identicon holds the mirrored 5x5 cell pattern and colour for a hash.
+type identicon struct {
+	cells [identiconGrid][identiconGrid]bool
+	fg    color.RGBA
+}
+
+var identiconBackground = color.RGBA{0xf0, 0xf0, 0xf0, 0xff}
+
+func newIdenticon(hash string) identicon {
+	b, _ := hex.DecodeString(hash)
+	var id identicon
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < (identiconGrid+1)/2; col++ {
+			on := b[row*3+col]&1 == 1
+			id.cells[row][col] = on
+			id.cells[row][identiconGrid-1-col] = on
+		}
+	}
+	id.fg = hslToRGB(float64(b[15])/255*360, 0.55, 0.5)
+	return id
+}
+
+// RenderIdenticonPNG draws the identicon for hash as a size x size PNG.
+func RenderIdenticonPNG(hash string, size int) ([]byte, error) {
+	id := newIdenticon(hash)
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), &image.Uniform{identiconBackground}, image.Point{}, draw.Src)
+	cell := size / (identiconGrid + 1)
+	margin := (size - cell*identiconGrid) / 2
+	fg := &image.Uniform{id.fg}
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < identiconGrid; col++ {
+			if !id.cells[row][col] {
+				continue
+			}
+			r := image.Rect(margin+col*cell, margin+row*cell, margin+(col+1)*cell, margin+(row+1)*cell)
+			draw.Draw(img, r, fg, image.Point{}, draw.Src)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderIdenticonSVG draws the identicon for hash as an SVG document.
+func RenderIdenticonSVG(hash string, size int) string {
+	id := newIdenticon(hash)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 12 12" shape-rendering="crispEdges">`, size, size)
+	fmt.Fprintf(&b, `<rect width="12" height="12" fill="#%02x%02x%02x"/>`, identiconBackground.R, identiconBackground.G, identiconBackground.B)
+	fmt.Fprintf(&b, `<g fill="#%02x%02x%02x">`, id.fg.R, id.fg.G, id.fg.B)
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < identiconGrid; col++ {
+			if id.cells[row][col] {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="2" height="2"/>`, 1+col*2, 1+row*2)
+			}
+		}
+	}
+	b.WriteString(`</g></svg>`)
+	return b.String()
+}
+
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - abs(2*l-1)) * s
+	x := c * (1 - abs(mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 0xff}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func mod(a, b float64) float64 {
+	return a - b*float64(int(a/b))
+}
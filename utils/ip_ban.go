@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"post-comments-api/models"
+)
+
+type ipBanEntry struct {
+	prefix netip.Prefix
+	ban    models.IPBan
+}
+
+const ipBanCacheTTL = 30 * time.Second
+
+var (
+	ipBansMu       sync.Mutex
+	ipBans         []ipBanEntry
+	ipBansLoadedAt time.Time
+)
+
+// ParseIPBanTarget parses an IP address or CIDR range. Addresses become
+// single-address ranges and host bits of ranges are cleared, so equal bans
+// have equal strings.
+func ParseIPBanTarget(target string) (netip.Prefix, error) {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "/") {
+		prefix, err := netip.ParsePrefix(target)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(target)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// FindIPBan returns the active ban covering ip, or nil if it is not banned.
+func FindIPBan(ip string) *models.IPBan {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap()
+	now := time.Now()
+	for _, entry := range loadIPBans() {
+		if entry.prefix.Contains(addr) && (entry.ban.ExpiresAt == nil || entry.ban.ExpiresAt.After(now)) {
+			ban := entry.ban
+			return &ban
+		}
+	}
+	return nil
+}
+
+// InvalidateIPBans makes the next check reload bans from the database.
+func InvalidateIPBans() {
+	ipBansMu.Lock()
+	defer ipBansMu.Unlock()
+	ipBansLoadedAt = time.Time{}
+}
+
+func loadIPBans() []ipBanEntry {
+	ipBansMu.Lock()
+	defer ipBansMu.Unlock()
+	if time.Since(ipBansLoadedAt) < ipBanCacheTTL {
+		return ipBans
+	}
+	var bans []models.IPBan
+	if err := GetDB().Where("expires_at IS NULL OR expires_at > ?", time.Now()).Find(&bans).Error; err != nil {
+		log.Error().Err(err).Msg("failed to load IP bans")
+		return ipBans
+	}
+	entries := make([]ipBanEntry, 0, len(bans))
+	for _, ban := range bans {
+		prefix, err := ParseIPBanTarget(ban.CIDR)
+		if err != nil {
+			log.Warn().Err(err).Uint("ban_id", ban.ID).Msg("skipping invalid IP ban")
+			continue
+		}
+		entries = append(entries, ipBanEntry{prefix: prefix, ban: ban})
+	}
+	ipBans = entries
+	ipBansLoadedAt = time.Now()
+	return ipBans
+}
@@ -0,0 +1,34 @@
+package utils
+
+import "testing"
+
+func TestParseIPBanTarget(t *testing.T) {
+	tests := []struct {
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{"203.0.113.7", "203.0.113.7/32", false},
+		{" 203.0.113.7 ", "203.0.113.7/32", false},
+		{"203.0.113.7/24", "203.0.113.0/24", false},
+		{"::ffff:203.0.113.7", "203.0.113.7/32", false},
+		{"::ffff:203.0.113.0/120", "203.0.113.0/24", false},
+		{"2001:db8::1", "2001:db8::1/128", false},
+		{"2001:db8:1:2:3::/64", "2001:db8:1:2::/64", false},
+		{"0.0.0.0/0", "0.0.0.0/0", false},
+		{"203.0.113.300", "", true},
+		{"203.0.113.0/33", "", true},
+		{"example.com", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseIPBanTarget(tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseIPBanTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParseIPBanTarget(%q) = %s, want %s", tt.target, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,71 @@
 package utils
 
 import (
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"post-comments-api/config"
+	"post-comments-api/models"
 )
 
-func GenerateJWT(userID uint) (string, error) {
+const (
+	TokenTTL        = 72 * time.Hour
+	mfaChallengeTTL = 5 * time.Minute
+)
+
+// GenerateJWT issues an access token bound to the given login session.
+func GenerateJWT(userID, sessionID uint) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(TokenTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// GenerateImpersonationJWT issues an access token for session, an
+// impersonation session of its user on behalf of the admin in
+// session.ImpersonatorID. It expires with the session and cannot be refreshed.
+func GenerateImpersonationJWT(session *models.Session) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": session.UserID,
+		"sid":     session.ID,
+		"act":     *session.ImpersonatorID,
+		"exp":     session.ExpiresAt.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(config.AppConfig.JWTSecret))
+}
+
+// GenerateMFAChallengeJWT issues the short-lived token returned by the password
+// step of login. It is only accepted by the MFA login step, never as an access token.
+func GenerateMFAChallengeJWT(userID uint) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
+		"purpose": "mfa",
+		"exp":     time.Now().Add(mfaChallengeTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(config.AppConfig.JWTSecret))
 }
+
+// ParseMFAChallengeJWT validates an MFA challenge token and returns its user ID.
+func ParseMFAChallengeJWT(tokenStr string) (uint, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		return []byte(config.AppConfig.JWTSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return 0, errors.New("invalid challenge token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != "mfa" {
+		return 0, errors.New("invalid challenge token")
+	}
+	uid, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, errors.New("invalid challenge token")
+	}
+	return uint(uid), nil
+}
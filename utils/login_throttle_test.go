@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"post-comments-api/config"
+	"post-comments-api/models"
+)
+
+func withLoginConfig(t *testing.T) {
+	t.Helper()
+	prev := config.AppConfig
+	config.AppConfig = &config.Config{
+		LoginAttemptWindow: 15 * time.Minute,
+		LoginLockoutBase:   time.Minute,
+		LoginLockoutMax:    time.Hour,
+	}
+	t.Cleanup(func() { config.AppConfig = prev })
+}
+
+func TestApplyLoginFailureBackoffGrowsAcrossLocks(t *testing.T) {
+	withLoginConfig(t)
+	const maxAttempts = 3
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var th models.LoginThrottle
+	for i := 0; i < maxAttempts-1; i++ {
+		applyLoginFailure(&th, now, maxAttempts)
+		now = now.Add(time.Second)
+	}
+	if th.LockedUntil != nil {
+		t.Fatalf("locked after %d failures", th.Failures)
+	}
+	// Each further failure lands just after the previous lock expires, which
+	// for the later cycles is well past the 15 minute attempt window.
+	want := []time.Duration{1, 2, 4, 8, 16, 32, 60, 60}
+	for i, w := range want {
+		applyLoginFailure(&th, now, maxAttempts)
+		if th.LockedUntil == nil {
+			t.Fatalf("cycle %d: not locked", i)
+		}
+		if got := th.LockedUntil.Sub(now); got != w*time.Minute {
+			t.Errorf("cycle %d: lock = %v, want %v", i, got, w*time.Minute)
+		}
+		now = th.LockedUntil.Add(time.Second)
+	}
+}
+
+func TestApplyLoginFailureResetsAfterQuietWindow(t *testing.T) {
+	withLoginConfig(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	lockedUntil := now.Add(time.Hour)
+	th := models.LoginThrottle{Failures: 10, LastFailureAt: now, LockedUntil: &lockedUntil}
+
+	applyLoginFailure(&th, lockedUntil.Add(16*time.Minute), 5)
+	if th.Failures != 1 {
+		t.Errorf("failures = %d, want 1", th.Failures)
+	}
+}
+
+func TestIPThrottleKey(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"203.0.113.7", "ip:203.0.113.7"},
+		{"::ffff:203.0.113.7", "ip:203.0.113.7"},
+		{"2001:db8:1:2:3:4:5:6", "ip:2001:db8:1:2::/64"},
+		{"2001:db8:1:2:ffff::1", "ip:2001:db8:1:2::/64"},
+		{"2001:db8:1:3::1", "ip:2001:db8:1:3::/64"},
+		{"not-an-ip", "ip:not-an-ip"},
+	}
+	for _, tt := range tests {
+		if got := IPThrottleKey(tt.ip); got != tt.want {
+			t.Errorf("IPThrottleKey(%q) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"regexp"
+	"time"
+
+	"post-comments-api/config"
+	"post-comments-api/models"
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// ContainsLink reports whether text contains a URL or Markdown link.
+func ContainsLink(text string) bool {
+	return linkPattern.MatchString(text)
+}
+
+// CountLinks returns the number of URLs in text.
+func CountLinks(text string) int {
+	return len(linkPattern.FindAllStringIndex(text, -1))
+}
+
+// InitialModerationState applies the configured pre-approval rules to new
+// content. author is nil for guest submissions.
+func InitialModerationState(author *models.User, texts ...string) string {
+	cfg := config.AppConfig
+	if author == nil && cfg.ModerateGuests {
+		return models.ModerationPending
+	}
+	if author != nil && author.Role != models.RoleUser {
+		return models.ModerationApproved
+	}
+	if author != nil && cfg.ModerateNewAccountDays > 0 &&
+		time.Since(author.CreatedAt) < time.Duration(cfg.ModerateNewAccountDays)*24*time.Hour {
+		return models.ModerationPending
+	}
+	if cfg.ModerateLinks {
+		for _, text := range texts {
+			if ContainsLink(text) {
+				return models.ModerationPending
+			}
+		}
+	}
+	return models.ModerationApproved
+}
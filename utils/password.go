@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"post-comments-api/config"
+)
+
+// PasswordHasher hashes and verifies passwords for a single algorithm. Hashes
+// are self-describing so parameters can change without breaking old ones.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	// Handles reports whether encoded was produced by this algorithm.
+	Handles(encoded string) bool
+	// Outdated reports whether encoded uses weaker parameters than the hasher.
+	Outdated(encoded string) bool
+}
+
+var errUnknownHashFormat = errors.New("unrecognized password hash format")
+
+// Argon2idHasher encodes hashes in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>
+type Argon2idHasher struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Threads, h.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Time, h.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Verify(encoded, password string) (bool, error) {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+func (h Argon2idHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$argon2id$")
+}
+
+func (h Argon2idHasher) Outdated(encoded string) bool {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p.Memory < h.Memory || p.Time < h.Time || p.Threads < h.Threads ||
+		uint32(len(salt)) < h.SaltLen || uint32(len(key)) < h.KeyLen
+}
+
+func decodeArgon2id(encoded string) (Argon2idHasher, []byte, []byte, error) {
+	var p Argon2idHasher
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, errUnknownHashFormat
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errUnknownHashFormat
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, errUnknownHashFormat
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errUnknownHashFormat
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errUnknownHashFormat
+	}
+	return p, salt, key, nil
+}
+
+// BcryptMaxPasswordBytes is the longest password bcrypt accepts; it works on
+// bytes, not characters.
+const BcryptMaxPasswordBytes = 72
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	return string(hash), err
+}
+
+func (h BcryptHasher) Verify(encoded, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (h BcryptHasher) Handles(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+func (h BcryptHasher) Outdated(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost < h.Cost
+}
+
+// passwordHashers returns the configured default hasher first, followed by
+// every other supported algorithm for verifying older hashes.
+func passwordHashers() []PasswordHasher {
+	cfg := config.AppConfig
+	argon := Argon2idHasher{
+		Memory:  cfg.Argon2Memory,
+		Time:    cfg.Argon2Time,
+		Threads: cfg.Argon2Threads,
+		SaltLen: 16,
+		KeyLen:  32,
+	}
+	bc := BcryptHasher{Cost: cfg.BcryptCost}
+	if cfg.PasswordHasher == "bcrypt" {
+		return []PasswordHasher{bc, argon}
+	}
+	return []PasswordHasher{argon, bc}
+}
+
+// HashPassword hashes password with the configured default algorithm.
+func HashPassword(password string) (string, error) {
+	return passwordHashers()[0].Hash(password)
+}
+
+// VerifyPassword checks password against encoded. When it matches, rehash
+// reports whether encoded uses an outdated algorithm or parameters and should
+// be replaced with HashPassword.
+func VerifyPassword(encoded, password string) (ok bool, rehash bool, err error) {
+	hashers := passwordHashers()
+	for i, h := range hashers {
+		if !h.Handles(encoded) {
+			continue
+		}
+		ok, err = h.Verify(encoded, password)
+		if err != nil || !ok {
+			return false, false, err
+		}
+		return true, i != 0 || h.Outdated(encoded), nil
+	}
+	return false, false, errUnknownHashFormat
+}
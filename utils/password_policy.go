@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"post-comments-api/config"
+)
+
+// PasswordViolation is one reason a password was rejected by the policy.
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var (
+	breachedOnce     sync.Once
+	breachedPrefixes map[string]map[string]struct{}
+)
+
+// CheckPasswordPolicy returns every policy rule password breaks, or nil if it
+// is acceptable for username.
+func CheckPasswordPolicy(password, username string) []PasswordViolation {
+	cfg := config.AppConfig
+	var violations []PasswordViolation
+	length := utf8.RuneCountInString(password)
+	if length < cfg.PasswordMinLength {
+		violations = append(violations, PasswordViolation{"too_short", fmt.Sprintf("Password must be at least %d characters", cfg.PasswordMinLength)})
+	}
+	if length > cfg.PasswordMaxLength {
+		violations = append(violations, PasswordViolation{"too_long", fmt.Sprintf("Password must be at most %d characters", cfg.PasswordMaxLength)})
+	} else if cfg.PasswordHasher == "bcrypt" && len(password) > BcryptMaxPasswordBytes {
+		violations = append(violations, PasswordViolation{"too_long", fmt.Sprintf("Password must be at most %d bytes; accented and non-Latin characters take 2 to 4 bytes each", BcryptMaxPasswordBytes)})
+	}
+	if passwordEntropy(password) < cfg.PasswordMinEntropy {
+		violations = append(violations, PasswordViolation{"too_weak", "Password is too predictable; use a longer mix of words, numbers and symbols"})
+	}
+	if similarToUsername(password, username) {
+		violations = append(violations, PasswordViolation{"similar_to_username", "Password must not contain or resemble the username"})
+	}
+	if IsBreachedPassword(password) {
+		violations = append(violations, PasswordViolation{"breached", "Password has appeared in a known data breach"})
+	}
+	return violations
+}
+
+// commonPasswordWords are words and keyboard walks that top leaked password
+// lists. A match counts as one pick from this list rather than as random
+// letters.
+var commonPasswordWords = []string{
+	"password", "passwd", "pass", "letmein", "welcome", "changeme", "access",
+	"qwerty", "qwertz", "azerty", "asdf", "zxcv", "admin", "login", "master",
+	"dragon", "monkey", "shadow", "sunshine", "princess", "football", "baseball",
+	"soccer", "hockey", "iloveyou", "love", "hello", "freedom", "whatever",
+	"secret", "summer", "winter", "spring", "autumn", "superman", "batman",
+	"trustno", "starwars", "computer", "internet", "pokemon", "flower", "cheese",
+	"mustang", "michael", "charlie", "ninja", "jesus", "god", "abc",
+}
+
+var leetSubstitutions = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's', '!': 'i',
+}
+
+// passwordEntropy estimates entropy in bits. It discounts the usual way
+// people build passwords: a capitalised first letter, common words with
+// letter-for-digit swaps, and digits or symbols tacked on the end. Each of
+// those parts is worth a few bits at most, so "Password1!" stays weak. The
+// remaining characters are scored by runEntropy.
+func passwordEntropy(password string) float64 {
+	runes := []rune(password)
+	end := len(runes)
+	for end > 0 && !unicode.IsLetter(runes[end-1]) {
+		end--
+	}
+	core, suffix := runes[:end], runes[end:]
+	bits := runEntropy(suffix)
+	if len(core) > 1 && unicode.IsUpper(core[0]) && !containsUpper(core[1:]) {
+		bits++
+		core = append([]rune{unicode.ToLower(core[0])}, core[1:]...)
+	}
+	plain := make([]rune, len(core))
+	for i, r := range core {
+		if sub, ok := leetSubstitutions[r]; ok {
+			r = sub
+		}
+		plain[i] = unicode.ToLower(r)
+	}
+	wordBits := math.Log2(float64(len(commonPasswordWords)))
+	var rest []rune
+	for i := 0; i < len(core); {
+		if n := commonWordAt(plain[i:]); n > 0 {
+			bits += wordBits
+			i += n
+			continue
+		}
+		rest = append(rest, core[i])
+		i++
+	}
+	return bits + runEntropy(rest)
+}
+
+// commonWordAt returns the length of the longest common password word that
+// s starts with, or 0.
+func commonWordAt(s []rune) int {
+	longest := 0
+	for _, w := range commonPasswordWords {
+		if len(w) > longest && len(w) <= len(s) && string(s[:len(w)]) == w {
+			longest = len(w)
+		}
+	}
+	return longest
+}
+
+func containsUpper(runes []rune) bool {
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// runEntropy scores runes by the character classes used. Characters that
+// repeat or continue a run (abc, 321) from the previous character add nothing.
+func runEntropy(runes []rune) float64 {
+	var lower, upper, digit, symbol, other bool
+	effective := 0
+	var prev rune = -1
+	for _, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+		if prev == -1 || (r != prev && r != prev+1 && r != prev-1) {
+			effective++
+		}
+		prev = r
+	}
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(effective) * math.Log2(float64(pool))
+}
+
+func similarToUsername(password, username string) bool {
+	p := strings.ToLower(password)
+	u := strings.ToLower(username)
+	if len(u) < 3 {
+		return p == u
+	}
+	stripped := strings.TrimFunc(p, func(r rune) bool { return !unicode.IsLetter(r) })
+	return strings.Contains(p, u) || strings.Contains(u, p) || stripped == u || reverseString(p) == u
+}
+
+func reverseString(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// IsBreachedPassword looks password up in the local breached-password list.
+// The list is keyed by the first five hex characters of the SHA-1 hash, the
+// same k-anonymity layout as the Pwned Passwords range API.
+func IsBreachedPassword(password string) bool {
+	breachedOnce.Do(loadBreachedPasswords)
+	if breachedPrefixes == nil {
+		return false
+	}
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	_, found := breachedPrefixes[hash[:5]][hash[5:]]
+	return found
+}
+
+// loadBreachedPasswords reads PASSWORD_BREACHED_FILE, one SHA-1 hash per line
+// optionally followed by ":<count>". Without a file the check is skipped.
+func loadBreachedPasswords() {
+	path := config.AppConfig.PasswordBreachedFile
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("breached password list not loaded")
+		return
+	}
+	defer f.Close()
+	prefixes := make(map[string]map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if len(line) != 40 {
+			continue
+		}
+		line = strings.ToUpper(line)
+		if prefixes[line[:5]] == nil {
+			prefixes[line[:5]] = make(map[string]struct{})
+		}
+		prefixes[line[:5]][line[5:]] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("breached password list not loaded")
+		return
+	}
+	breachedPrefixes = prefixes
+}
@@ -0,0 +1,71 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"post-comments-api/config"
+)
+
+func TestCheckPasswordPolicy(t *testing.T) {
+	const breached = "Xk9#mQ2$vL7pZ"
+	sum := sha1.Sum([]byte(breached))
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.ToUpper(hex.EncodeToString(sum[:]))+":42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prev := config.AppConfig
+	t.Cleanup(func() {
+		config.AppConfig = prev
+		breachedOnce = sync.Once{}
+		breachedPrefixes = nil
+	})
+	breachedOnce = sync.Once{}
+	breachedPrefixes = nil
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		hasher   string
+		want     []string
+	}{
+		{"random characters", "hT7#qLp2$wZx", "alice", "argon2id", nil},
+		{"passphrase", "correct horse battery staple", "alice", "argon2id", nil},
+		{"too short", "aB3$x", "alice", "argon2id", []string{"too_short", "too_weak"}},
+		{"capitalised word with suffix", "Password1!", "alice", "argon2id", []string{"too_weak"}},
+		{"leet word with year", "P4ssw0rd2024", "alice", "argon2id", []string{"too_weak"}},
+		{"two common words", "SunshineDragon99", "alice", "argon2id", []string{"too_weak"}},
+		{"repeated characters", "aaaaaaaaaaaaaaaa", "alice", "argon2id", []string{"too_weak"}},
+		{"sequential digits", "1234567890123", "alice", "argon2id", []string{"too_weak"}},
+		{"contains username", "alice-hT7#qLp2$w", "alice", "argon2id", []string{"similar_to_username"}},
+		{"reversed username", "ecilaecila", "alicealice", "argon2id", []string{"similar_to_username"}},
+		{"over bcrypt byte limit", strings.Repeat("hT7#qLp2$wZé", 6), "alice", "bcrypt", []string{"too_long"}},
+		{"within limit for argon2id", strings.Repeat("hT7#qLp2$wZé", 6), "alice", "argon2id", nil},
+		{"breached", breached, "alice", "argon2id", []string{"breached"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.AppConfig = &config.Config{
+				PasswordHasher:       tt.hasher,
+				PasswordMinLength:    8,
+				PasswordMaxLength:    128,
+				PasswordMinEntropy:   40,
+				PasswordBreachedFile: path,
+			}
+			var got []string
+			for _, v := range CheckPasswordPolicy(tt.password, tt.username) {
+				got = append(got, v.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckPasswordPolicy(%q) = %v, want %v (entropy %.1f)", tt.password, got, tt.want, passwordEntropy(tt.password))
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package utils
+
+import (
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// SecurityLog starts a structured log event for security-relevant activity,
+// tagged so it can be filtered out of the general request log.
+func SecurityLog(event string) *zerolog.Event {
+	return log.Warn().Str("category", "security").Str("event", event)
+}
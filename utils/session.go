@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"post-comments-api/config"
+	"post-comments-api/models"
+)
+
+// CreateSession records a new login session for userID.
+func CreateSession(userID uint, userAgent, ip string) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:     userID,
+		UserAgent:  TruncateString(userAgent, 255),
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(TokenTTL),
+	}
+	if err := GetDB().Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CreateImpersonationSession records the session behind an impersonation
+// token, so it can be signed out like any other. It expires with the token.
+func CreateImpersonationSession(tx *gorm.DB, userID, actorID uint, userAgent, ip string) (*models.Session, error) {
+	now := time.Now()
+	session := models.Session{
+		UserID:         userID,
+		UserAgent:      TruncateString(userAgent, 255),
+		IP:             ip,
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(config.AppConfig.ImpersonationTTL),
+		ImpersonatorID: &actorID,
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeUserSessions signs out every active session of userID using tx.
+func RevokeUserSessions(tx *gorm.DB, userID uint) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"post-comments-api/config"
+)
+
+const (
+	SessionCookieName = "session"
+	CSRFCookieName    = "csrf_token"
+	CSRFHeaderName    = "X-CSRF-Token"
+)
+
+// CSRFToken derives the double-submit token for a session. Binding it to the
+// session ID means a token planted by an attacker for another session fails.
+func CSRFToken(sessionID uint) string {
+	mac := hmac.New(sha256.New, []byte(config.AppConfig.JWTSecret))
+	mac.Write([]byte("csrf:" + strconv.FormatUint(uint64(sessionID), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SetSessionCookies writes the HttpOnly session cookie holding the access
+// token and the script-readable CSRF cookie.
+func SetSessionCookies(w http.ResponseWriter, token, csrf string) {
+	maxAge := int(TokenTTL.Seconds())
+	http.SetCookie(w, sessionCookie(SessionCookieName, token, maxAge, true))
+	http.SetCookie(w, sessionCookie(CSRFCookieName, csrf, maxAge, false))
+}
+
+func ClearSessionCookies(w http.ResponseWriter) {
+	http.SetCookie(w, sessionCookie(SessionCookieName, "", -1, true))
+	http.SetCookie(w, sessionCookie(CSRFCookieName, "", -1, false))
+}
+
+func sessionCookie(name, value string, maxAge int, httpOnly bool) *http.Cookie {
+	cfg := config.AppConfig
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   cfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: parseSameSite(cfg.CookieSameSite),
+	}
+}
+
+func parseSameSite(mode string) http.SameSite {
+	switch strings.ToLower(mode) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+)
+
+// ContentHash identifies exact duplicates. Text is normalized first so case,
+// spacing and look-alike characters do not make a repost look new.
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fingerprintWords(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHashMinWords is the fewest words worth a SimHash. Shorter texts such as
+// "thanks" or "+1" have too few features to compare meaningfully.
+const SimHashMinWords = 5
+
+// SimHash computes a 64-bit locality-sensitive fingerprint of text from its
+// words and word pairs. Similar texts differ in only a few bits. Texts under
+// SimHashMinWords words get 0, which is never compared.
+func SimHash(text string) uint64 {
+	words := fingerprintWords(text)
+	if len(words) < SimHashMinWords {
+		return 0
+	}
+	var weights [64]int
+	add := func(feature string) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+	for i, word := range words {
+		add(word)
+		if i > 0 {
+			add(words[i-1] + " " + word)
+		}
+	}
+	var fingerprint uint64
+	for i, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(i)
+		}
+	}
+	return fingerprint
+}
+
+// HammingDistance counts the bits that differ between two fingerprints.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+func fingerprintWords(text string) []string {
+	normalized, _ := NormalizeForFilter(text)
+	return strings.FieldsFunc(normalized, func(r rune) bool {
+		return !isWordRune(r)
+	})
+}
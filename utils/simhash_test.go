@@ -0,0 +1,64 @@
+package utils
+
+import "testing"
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0b1011, 0b1011, 0},
+		{0b1011, 0b0010, 2},
+		{0, ^uint64(0), 64},
+		{1 << 63, 1, 2},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%b, %b) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimHashDistance(t *testing.T) {
+	const base = "The quick brown fox jumps over the lazy dog while the farmer watches from the porch and drinks his morning coffee"
+	// maxDistance matches the DUPLICATE_MAX_DISTANCE default.
+	const maxDistance = 8
+	tests := []struct {
+		name  string
+		other string
+		near  bool
+	}{
+		{"identical", base, true},
+		{"case and punctuation", "THE QUICK brown fox, jumps over the lazy dog... while the farmer watches from the porch and drinks his morning coffee!", true},
+		{"fullwidth and homoglyphs", "The ｑｕｉｃｋ brown f0x jumps over the lazy dog while the farmer watches from the porch and drinks his morning coffee", true},
+		{"one word changed", "The quick brown fox jumps over the lazy cat while the farmer watches from the porch and drinks his morning coffee", true},
+		{"unrelated", "Quarterly earnings beat analyst expectations as cloud revenue grew faster than any other segment this year", false},
+	}
+	want := SimHash(base)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := HammingDistance(want, SimHash(tt.other))
+			if near := d <= maxDistance; near != tt.near {
+				t.Errorf("distance = %d, near = %v, want %v", d, near, tt.near)
+			}
+		})
+	}
+}
+
+func TestSimHashShortText(t *testing.T) {
+	for _, text := range []string{"", "thanks", "+1", "great post, thank you"} {
+		if got := SimHash(text); got != 0 {
+			t.Errorf("SimHash(%q) = %x, want 0", text, got)
+		}
+	}
+}
+
+func TestContentHash(t *testing.T) {
+	if ContentHash("Hello,  WORLD!") != ContentHash("hello world") {
+		t.Error("normalized texts hash differently")
+	}
+	if ContentHash("hello world") == ContentHash("hello there") {
+		t.Error("different texts hash the same")
+	}
+}
@@ -0,0 +1,280 @@
+package utils
+
+import (
+	"bufio"
+	"math"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"post-comments-api/config"
+	"post-comments-api/models"
+)
+
+// SpamResult is the outcome of scoring a comment. Score runs from 0 (ham) to
+// 1 (spam); Reasons names the heuristics that fired.
+type SpamResult struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+const (
+	spamInterestingTokens = 15
+	spamMaxTokenLength    = 64
+)
+
+var (
+	badDomainsOnce sync.Once
+	badDomains     map[string]struct{}
+)
+
+// ScoreSpam runs the heuristic rules and the naive Bayes classifier over
+// content and combines them into a single score.
+func ScoreSpam(content string) SpamResult {
+	cfg := config.AppConfig
+	var result SpamResult
+	heuristic := 0.0
+	links := linkPattern.FindAllString(content, -1)
+	if len(links) > cfg.SpamMaxLinks {
+		heuristic += 0.4
+		result.Reasons = append(result.Reasons, "too_many_links")
+	}
+	for _, link := range links {
+		if isBadDomain(link) {
+			heuristic += 1
+			result.Reasons = append(result.Reasons, "bad_domain")
+			break
+		}
+	}
+	if isRepetitive(content) {
+		heuristic += 0.3
+		result.Reasons = append(result.Reasons, "repetitive_text")
+	}
+	if recentlyPosted(content) {
+		heuristic += 0.3
+		result.Reasons = append(result.Reasons, "repeated_content")
+	}
+	heuristic = math.Min(heuristic, 1)
+	bayes, trained := bayesSpamProbability(spamTokens(content))
+	if !trained {
+		result.Score = heuristic
+		return result
+	}
+	// Either signal alone can mark a comment as spam.
+	result.Score = 1 - (1-heuristic)*(1-bayes)
+	return result
+}
+
+// TrainSpam records a moderator decision about content. oldLabel is the label
+// the content was previously trained with, if any, and is unlearned first so
+// a changed decision does not count twice.
+func TrainSpam(tx *gorm.DB, content, oldLabel, newLabel string) error {
+	if oldLabel == newLabel {
+		return nil
+	}
+	tokens := spamTokens(content)
+	if oldLabel != "" {
+		if err := adjustSpamCounts(tx, tokens, oldLabel, -1); err != nil {
+			return err
+		}
+	}
+	if newLabel != "" {
+		return adjustSpamCounts(tx, tokens, newLabel, 1)
+	}
+	return nil
+}
+
+func adjustSpamCounts(tx *gorm.DB, tokens []string, label string, delta int) error {
+	column := "ham_count"
+	docsColumn := "ham_docs"
+	if label == models.SpamLabelSpam {
+		column = "spam_count"
+		docsColumn = "spam_docs"
+	}
+	corpus := models.SpamCorpus{ID: 1}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&corpus).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&corpus).Update(docsColumn, gorm.Expr("GREATEST("+docsColumn+" + ?, 0)", delta)).Error; err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+	rows := make([]models.SpamToken, 0, len(tokens))
+	for _, token := range tokens {
+		row := models.SpamToken{Token: token}
+		if delta > 0 {
+			if label == models.SpamLabelSpam {
+				row.SpamCount = delta
+			} else {
+				row.HamCount = delta
+			}
+		}
+		rows = append(rows, row)
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr("GREATEST(spam_tokens."+column+" + ?, 0)", delta)}),
+	}).Create(&rows).Error
+}
+
+// bayesSpamProbability combines the most telling tokens with Robinson's
+// smoothing. trained is false until enough spam and ham has been labelled.
+func bayesSpamProbability(tokens []string) (probability float64, trained bool) {
+	var corpus models.SpamCorpus
+	if err := GetDB().First(&corpus, 1).Error; err != nil {
+		return 0, false
+	}
+	minDocs := config.AppConfig.SpamMinTrainingDocs
+	if corpus.SpamDocs < minDocs || corpus.HamDocs < minDocs || corpus.SpamDocs == 0 || corpus.HamDocs == 0 || len(tokens) == 0 {
+		return 0, false
+	}
+	var known []models.SpamToken
+	if err := GetDB().Where("token IN ?", tokens).Find(&known).Error; err != nil {
+		return 0, false
+	}
+	probabilities := make([]float64, 0, len(known))
+	for _, token := range known {
+		n := float64(token.SpamCount + token.HamCount)
+		if n == 0 {
+			continue
+		}
+		spamFreq := float64(token.SpamCount) / float64(corpus.SpamDocs)
+		hamFreq := float64(token.HamCount) / float64(corpus.HamDocs)
+		p := spamFreq / (spamFreq + hamFreq)
+		p = (0.5 + n*p) / (1 + n)
+		probabilities = append(probabilities, math.Min(math.Max(p, 0.01), 0.99))
+	}
+	if len(probabilities) == 0 {
+		return 0.5, true
+	}
+	sort.Slice(probabilities, func(i, j int) bool {
+		return math.Abs(probabilities[i]-0.5) > math.Abs(probabilities[j]-0.5)
+	})
+	if len(probabilities) > spamInterestingTokens {
+		probabilities = probabilities[:spamInterestingTokens]
+	}
+	logOdds := 0.0
+	for _, p := range probabilities {
+		logOdds += math.Log(p / (1 - p))
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true
+}
+
+// spamTokens returns the distinct lowercase words in content, plus a
+// "host:" token for each linked domain.
+func spamTokens(content string) []string {
+	seen := make(map[string]struct{})
+	var tokens []string
+	add := func(token string) {
+		if _, ok := seen[token]; ok || len(token) > spamMaxTokenLength {
+			return
+		}
+		seen[token] = struct{}{}
+		tokens = append(tokens, token)
+	}
+	for _, link := range linkPattern.FindAllString(content, -1) {
+		if host := linkHost(link); host != "" {
+			add("host:" + host)
+		}
+	}
+	text := linkPattern.ReplaceAllString(content, " ")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) >= 2 {
+			add(word)
+		}
+	}
+	return tokens
+}
+
+// isRepetitive reports whether a longer text mostly repeats the same few words.
+func isRepetitive(content string) bool {
+	words := strings.Fields(strings.ToLower(content))
+	if len(words) < 10 {
+		return false
+	}
+	unique := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		unique[word] = struct{}{}
+	}
+	return float64(len(unique))/float64(len(words)) < 0.3
+}
+
+// recentlyPosted reports whether a comment with the same normalized text was
+// posted in the last day, using the indexed content hash.
+func recentlyPosted(content string) bool {
+	var count int64
+	GetDB().Model(&models.Comment{}).
+		Where("content_hash = ? AND created_at > ?", ContentHash(content), time.Now().Add(-24*time.Hour)).
+		Limit(1).Count(&count)
+	return count > 0
+}
+
+func linkHost(link string) string {
+	if !strings.Contains(link, "://") {
+		link = "http://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// isBadDomain reports whether link points at a domain, or a subdomain of one,
+// in SPAM_BAD_DOMAINS_FILE.
+func isBadDomain(link string) bool {
+	badDomainsOnce.Do(loadBadDomains)
+	host := linkHost(link)
+	for host != "" {
+		if _, ok := badDomains[host]; ok {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return false
+}
+
+// loadBadDomains reads SPAM_BAD_DOMAINS_FILE, one domain per line. Blank lines
+// and lines starting with # are ignored.
+func loadBadDomains() {
+	path := config.AppConfig.SpamBadDomainsFile
+	if path == "" {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("bad domain list not loaded")
+		return
+	}
+	defer f.Close()
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.TrimPrefix(line, "www.")] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn().Err(err).Str("path", path).Msg("bad domain list not loaded")
+		return
+	}
+	badDomains = domains
+}
@@ -0,0 +1,17 @@
+package utils
+
+import "strings"
+
+// TruncateString cuts s to at most n characters so it fits a varchar(n)
+// column. Invalid UTF-8, which Postgres rejects, is replaced first.
+func TruncateString(s string, n int) string {
+	s = strings.ToValidUTF8(s, "�")
+	count := 0
+	for i := range s {
+		if count == n {
+			return s[:i]
+		}
+		count++
+	}
+	return s
+}
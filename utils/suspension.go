@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"time"
+
+	"post-comments-api/models"
+)
+
+// IsSuspended reports whether user is currently suspended. A suspension with
+// no end time is a permanent ban.
+func IsSuspended(user *models.User) bool {
+	if user.SuspendedAt == nil {
+		return false
+	}
+	return user.SuspendedUntil == nil || user.SuspendedUntil.After(time.Now())
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed from RFC 6238 appendix B, base32 encoded.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	key, err := base32NoPad.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The RFC lists 8-digit codes; the last six digits are the 6-digit code.
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, uint64(tt.unix/totpPeriod)); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := now.Unix() / totpPeriod
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       time.Time
+		lastStep int64
+		wantOK   bool
+		wantStep int64
+	}{
+		{"current step", rfc6238Secret, "005924", now, 0, true, step},
+		{"lowercase secret", strings.ToLower(rfc6238Secret), "005924", now, 0, true, step},
+		{"clock behind by one step", rfc6238Secret, "005924", now.Add(-totpPeriod * time.Second), 0, true, step},
+		{"clock ahead by one step", rfc6238Secret, "005924", now.Add(totpPeriod * time.Second), 0, true, step},
+		{"clock ahead by two steps", rfc6238Secret, "005924", now.Add(2 * totpPeriod * time.Second), 0, false, 0},
+		{"wrong code", rfc6238Secret, "005925", now, 0, false, 0},
+		{"short code", rfc6238Secret, "05924", now, 0, false, 0},
+		{"invalid secret", "not base32!", "005924", now, 0, false, 0},
+		{"replayed step", rfc6238Secret, "005924", now, step, false, 0},
+		{"step before last used", rfc6238Secret, "005924", now, step + 1, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tt.secret, tt.code, tt.at, tt.lastStep)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	key, err := base32NoPad.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1234567890, 0)
+	code := totpCode(key, uint64(now.Unix()/totpPeriod))
+	step, ok := ValidateTOTP(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+	// Within the skew window the same code stays valid, so only lastStep
+	// stops it being used twice.
+	if _, ok := ValidateTOTP(rfc6238Secret, code, now.Add(20*time.Second), step); ok {
+		t.Error("replayed code accepted")
+	}
+	next := now.Add(totpPeriod * time.Second)
+	nextCode := totpCode(key, uint64(next.Unix()/totpPeriod))
+	if got, ok := ValidateTOTP(rfc6238Secret, nextCode, next, step); !ok || got != step+1 {
+		t.Errorf("next code = (%d, %v), want (%d, true)", got, ok, step+1)
+	}
+}
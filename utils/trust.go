@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"time"
+
+	"post-comments-api/config"
+	"post-comments-api/models"
+)
+
+// TrustLevel works out user's trust level from their role, account age and
+// number of approved posts and comments.
+func TrustLevel(user *models.User) int {
+	if user.Role != models.RoleUser {
+		return models.TrustLevelStaff
+	}
+	cfg := config.AppConfig
+	age := time.Since(user.CreatedAt)
+	var posts, comments int64
+	GetDB().Model(&models.Post{}).Where("user_id = ? AND moderation_state = ?", user.ID, models.ModerationApproved).Count(&posts)
+	GetDB().Model(&models.Comment{}).Where("user_id = ? AND moderation_state = ?", user.ID, models.ModerationApproved).Count(&comments)
+	approved := int(posts + comments)
+	switch {
+	case age >= days(cfg.TrustMemberDays) && approved >= cfg.TrustMemberApproved:
+		return models.TrustLevelMember
+	case age >= days(cfg.TrustBasicDays) && approved >= cfg.TrustBasicApproved:
+		return models.TrustLevelBasic
+	default:
+		return models.TrustLevelNew
+	}
+}
+
+// TrustHourlyLimit is how many posts and comments a user at level may create
+// per hour. Zero means unlimited.
+func TrustHourlyLimit(level int) int {
+	switch level {
+	case models.TrustLevelNew:
+		return config.AppConfig.TrustNewHourlyLimit
+	case models.TrustLevelBasic:
+		return config.AppConfig.TrustBasicHourlyLimit
+	default:
+		return 0
+	}
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/text/unicode/norm"
+	"post-comments-api/models"
+)
+
+// WordFilterResult reports whether a filter in reject or queue mode matched.
+// Masking has already been applied.
+type WordFilterResult struct {
+	Reject bool
+	Queue  bool
+}
+
+type compiledWordFilter struct {
+	filter    models.WordFilter
+	re        *regexp.Regexp
+	wholeWord bool
+}
+
+const wordFilterCacheTTL = time.Minute
+
+var (
+	wordFiltersMu       sync.Mutex
+	wordFilters         []compiledWordFilter
+	wordFiltersLoadedAt time.Time
+)
+
+// homoglyphs folds look-alike letters from other scripts and common
+// substitutions onto the Latin letter they imitate.
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd',
+	'ӏ': 'l', 'ԛ': 'q', 'ԝ': 'w', 'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i',
+	'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+// NormalizeForFilter folds text to a canonical lowercase form for matching:
+// compatibility characters (fullwidth, ligatures, styled letters) are
+// decomposed, accents and invisible characters dropped, and homoglyphs
+// mapped to Latin. offsets[i] holds the byte range in text that produced
+// byte i of the result, so matches can be mapped back.
+func NormalizeForFilter(text string) (string, [][2]int) {
+	return normalizeText(text, true)
+}
+
+// normalizeForRegex is the lighter normalization regex filters run against:
+// NFKC and lowercasing with invisible characters dropped, but digits,
+// symbols and accents kept so patterns like \d+ or café still match.
+func normalizeForRegex(text string) (string, [][2]int) {
+	return normalizeText(text, false)
+}
+
+func normalizeText(text string, fold bool) (string, [][2]int) {
+	var b strings.Builder
+	offsets := make([][2]int, 0, len(text))
+	form := norm.NFKC
+	if fold {
+		form = norm.NFKD
+	}
+	for i, r := range text {
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			size = 1
+		}
+		for _, folded := range form.String(string(r)) {
+			if unicode.Is(unicode.Cf, folded) || (fold && unicode.Is(unicode.Mn, folded)) {
+				continue
+			}
+			folded = unicode.ToLower(folded)
+			if mapped, ok := homoglyphs[folded]; ok && fold {
+				folded = mapped
+			}
+			n, _ := b.WriteRune(folded)
+			for j := 0; j < n; j++ {
+				offsets = append(offsets, [2]int{i, i + size})
+			}
+		}
+	}
+	return b.String(), offsets
+}
+
+// InvalidateWordFilters makes the next check reload filters from the database.
+func InvalidateWordFilters() {
+	wordFiltersMu.Lock()
+	defer wordFiltersMu.Unlock()
+	wordFiltersLoadedAt = time.Time{}
+}
+
+// CompileWordFilter builds the matcher for filter. Plain patterns match whole
+// words or phrases after full normalization; regex patterns are matched as
+// given against the lighter normalizeForRegex form.
+func CompileWordFilter(filter models.WordFilter) (*regexp.Regexp, error) {
+	if filter.IsRegex {
+		return regexp.Compile("(?i)" + filter.Pattern)
+	}
+	normalized, _ := NormalizeForFilter(strings.TrimSpace(filter.Pattern))
+	words := strings.Fields(normalized)
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return regexp.Compile(strings.Join(words, `\s+`))
+}
+
+func loadWordFilters() []compiledWordFilter {
+	wordFiltersMu.Lock()
+	defer wordFiltersMu.Unlock()
+	if time.Since(wordFiltersLoadedAt) < wordFilterCacheTTL {
+		return wordFilters
+	}
+	var filters []models.WordFilter
+	if err := GetDB().Find(&filters).Error; err != nil {
+		log.Error().Err(err).Msg("failed to load word filters")
+		return wordFilters
+	}
+	compiled := make([]compiledWordFilter, 0, len(filters))
+	for _, filter := range filters {
+		re, err := CompileWordFilter(filter)
+		if err != nil {
+			log.Warn().Err(err).Uint("filter_id", filter.ID).Msg("skipping invalid word filter")
+			continue
+		}
+		compiled = append(compiled, compiledWordFilter{filter: filter, re: re, wholeWord: !filter.IsRegex})
+	}
+	wordFilters = compiled
+	wordFiltersLoadedAt = time.Now()
+	return wordFilters
+}
+
+// ApplyWordFilters checks texts against the word filters. Matches of
+// mask-mode filters are replaced with asterisks in place.
+func ApplyWordFilters(texts ...*string) WordFilterResult {
+	var result WordFilterResult
+	filters := loadWordFilters()
+	if len(filters) == 0 {
+		return result
+	}
+	for _, text := range texts {
+		folded, foldedOffsets := NormalizeForFilter(*text)
+		plain, plainOffsets := normalizeForRegex(*text)
+		var masks [][2]int
+		for _, f := range filters {
+			normalized, offsets := folded, foldedOffsets
+			if f.filter.IsRegex {
+				normalized, offsets = plain, plainOffsets
+			}
+			matches := wordFilterMatches(f, normalized)
+			if len(matches) == 0 {
+				continue
+			}
+			switch f.filter.Mode {
+			case models.WordFilterReject:
+				result.Reject = true
+			case models.WordFilterQueue:
+				result.Queue = true
+			case models.WordFilterMask:
+				for _, m := range matches {
+					masks = append(masks, [2]int{offsets[m[0]][0], offsets[m[1]-1][1]})
+				}
+			}
+		}
+		if len(masks) > 0 {
+			*text = maskRanges(*text, masks)
+		}
+	}
+	return result
+}
+
+// wordFilterMatches returns the byte ranges in normalized matched by f. Plain
+// patterns only count when not surrounded by other letters or digits.
+func wordFilterMatches(f compiledWordFilter, normalized string) [][]int {
+	var matches [][]int
+	for _, m := range f.re.FindAllStringIndex(normalized, -1) {
+		if m[0] == m[1] {
+			continue
+		}
+		if f.wholeWord {
+			before, _ := utf8.DecodeLastRuneInString(normalized[:m[0]])
+			after, _ := utf8.DecodeRuneInString(normalized[m[1]:])
+			if isWordRune(before) || isWordRune(after) {
+				continue
+			}
+		}
+		matches = append(matches, m)
+	}
+	return matches
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// maskRanges replaces each byte range of text with one asterisk per rune.
+func maskRanges(text string, ranges [][2]int) string {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var b strings.Builder
+	pos := 0
+	for _, r := range ranges {
+		if r[1] <= pos {
+			continue
+		}
+		if r[0] < pos {
+			r[0] = pos
+		}
+		b.WriteString(text[pos:r[0]])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[r[0]:r[1]])))
+		pos = r[1]
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"post-comments-api/models"
+)
+
+func TestNormalizeForFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"lowercase", "HeLLo", "hello"},
+		{"fullwidth", "ｂａｄ", "bad"},
+		{"accents", "Café", "cafe"},
+		{"ligature", "ﬁne", "fine"},
+		{"zero width space", "b\u200bad", "bad"},
+		{"cyrillic homoglyph", "b\u0430d", "bad"},
+		{"digit substitution", "b4d", "bad"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, offsets := NormalizeForFilter(tt.in)
+			if got != tt.want {
+				t.Errorf("NormalizeForFilter(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if len(offsets) != len(got) {
+				t.Errorf("len(offsets) = %d, want %d", len(offsets), len(got))
+			}
+		})
+	}
+}
+
+func TestNormalizeForFilterOffsets(t *testing.T) {
+	tests := []struct {
+		in   string
+		want [][2]int
+	}{
+		{"aB", [][2]int{{0, 1}, {1, 2}}},
+		{"ｂａ", [][2]int{{0, 3}, {3, 6}}},
+		{"ﬁx", [][2]int{{0, 3}, {0, 3}, {3, 4}}},
+		{"a\u200bé", [][2]int{{0, 1}, {4, 6}}},
+	}
+	for _, tt := range tests {
+		if _, got := NormalizeForFilter(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("offsets for %q = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func setWordFilters(t *testing.T, filters ...models.WordFilter) {
+	t.Helper()
+	compiled := make([]compiledWordFilter, 0, len(filters))
+	for _, filter := range filters {
+		re, err := CompileWordFilter(filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		compiled = append(compiled, compiledWordFilter{filter: filter, re: re, wholeWord: !filter.IsRegex})
+	}
+	wordFiltersMu.Lock()
+	wordFilters, wordFiltersLoadedAt = compiled, time.Now()
+	wordFiltersMu.Unlock()
+	t.Cleanup(func() {
+		wordFiltersMu.Lock()
+		wordFilters, wordFiltersLoadedAt = nil, time.Time{}
+		wordFiltersMu.Unlock()
+	})
+}
+
+func TestApplyWordFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter models.WordFilter
+		in     string
+		want   string
+		result WordFilterResult
+	}{
+		{"mask word", models.WordFilter{Pattern: "bad", Mode: models.WordFilterMask}, "a bad day", "a *** day", WordFilterResult{}},
+		{"mask fullwidth", models.WordFilter{Pattern: "bad", Mode: models.WordFilterMask}, "so ｂａｄ!", "so ***!", WordFilterResult{}},
+		{"mask across invisible char", models.WordFilter{Pattern: "bad", Mode: models.WordFilterMask}, "b\u200bad", "****", WordFilterResult{}},
+		{"mask every match", models.WordFilter{Pattern: "bad", Mode: models.WordFilterMask}, "Bad, b4d", "***, ***", WordFilterResult{}},
+		{"inside other word", models.WordFilter{Pattern: "bad", Mode: models.WordFilterMask}, "badge", "badge", WordFilterResult{}},
+		{"mask regex", models.WordFilter{Pattern: `\d{3}-\d{4}`, IsRegex: true, Mode: models.WordFilterMask}, "call 555-1234 now", "call ******** now", WordFilterResult{}},
+		{"reject phrase", models.WordFilter{Pattern: "buy now", Mode: models.WordFilterReject}, "BUY   now!", "BUY   now!", WordFilterResult{Reject: true}},
+		{"queue", models.WordFilter{Pattern: "casino", Mode: models.WordFilterQueue}, "best c\u0430sino", "best c\u0430sino", WordFilterResult{Queue: true}},
+		{"no match", models.WordFilter{Pattern: "casino", Mode: models.WordFilterReject}, "hello", "hello", WordFilterResult{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setWordFilters(t, tt.filter)
+			text := tt.in
+			if got := ApplyWordFilters(&text); got != tt.result {
+				t.Errorf("result = %+v, want %+v", got, tt.result)
+			}
+			if text != tt.want {
+				t.Errorf("text = %q, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskRangesOverlapping(t *testing.T) {
+	if got := maskRanges("abcdefgh", [][2]int{{4, 6}, {1, 3}, {2, 5}}); got != "a*****gh" {
+		t.Errorf("maskRanges = %q, want %q", got, "a*****gh")
+	}
+}